@@ -0,0 +1,527 @@
+// Package client talks to a Tic Tac Toe server for programs such as
+// bots, services and the command line frontend.
+//
+//	c := client.New(client.WithHost("http://localhost:8080"))
+//	resp, err := c.CreateGame(ctx, "mygame", tictactoe.X, tictactoe.GameOptions{})
+//
+// The seat tokens handed out by CreateGame, JoinGame and Matchmake
+// are remembered by game and sent with every action for that game.
+// Errors returned by the server can be checked for with errors.As,
+// such as *tictactoe.NotYourTurnErr.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/svolpe43/ttt/server/tictactoe"
+)
+
+// DefaultHost is the server used unless WithHost says otherwise.
+const DefaultHost = "http://shawnvolpe.com:8080"
+
+const (
+	// DefaultTimeout limits each request answered right away.
+	DefaultTimeout = 10 * time.Second
+	// DefaultPollTimeout limits the requests that wait on the server,
+	// which answers them on its own well before it runs out.
+	DefaultPollTimeout = 90 * time.Second
+)
+
+type Client interface {
+	ListGames(ctx context.Context, filter tictactoe.GameFilter) (*tictactoe.GameList, error)
+	CreateGame(ctx context.Context, id tictactoe.GameID, symbol tictactoe.Symbol, opts tictactoe.GameOptions) (*tictactoe.JoinResponse, error)
+	JoinGame(ctx context.Context, id tictactoe.GameID) (*tictactoe.JoinResponse, error)
+	Matchmake(ctx context.Context, opts tictactoe.GameOptions) (*tictactoe.JoinResponse, error)
+	EndGame(ctx context.Context, id tictactoe.GameID) error
+	GetGame(ctx context.Context, id tictactoe.GameID) (*tictactoe.GameState, error)
+	Poll(ctx context.Context, id tictactoe.GameID, hash string) (*tictactoe.GameState, error)
+	Move(ctx context.Context, id tictactoe.GameID, symbol tictactoe.Symbol, index int) (*tictactoe.GameState, error)
+	Resign(ctx context.Context, id tictactoe.GameID, symbol tictactoe.Symbol) (*tictactoe.GameState, error)
+	RequestTakeback(ctx context.Context, id tictactoe.GameID, symbol tictactoe.Symbol) (*tictactoe.GameState, error)
+	AnswerTakeback(ctx context.Context, id tictactoe.GameID, symbol tictactoe.Symbol, accept bool) (*tictactoe.GameState, error)
+	Rematch(ctx context.Context, id tictactoe.GameID, symbol tictactoe.Symbol) (*tictactoe.GameState, error)
+	History(ctx context.Context, id tictactoe.GameID) (*tictactoe.GameHistory, error)
+	Analyze(ctx context.Context, shape tictactoe.Shape, board []tictactoe.Symbol, turn tictactoe.Symbol) (*tictactoe.Analysis, error)
+	Subscribe(ctx context.Context, id tictactoe.GameID) (*Stream, error)
+	Follow(ctx context.Context, id tictactoe.GameID, fn func(tictactoe.GameState) error) error
+	Connect(ctx context.Context, id tictactoe.GameID, symbol tictactoe.Symbol) (Socket, error)
+	Watch(ctx context.Context, id tictactoe.GameID) (Socket, error)
+	Register(ctx context.Context, name string) (*tictactoe.Account, error)
+	Login(name, key string)
+	Name() string
+	ClaimSeat(ctx context.Context, id tictactoe.GameID, symbol tictactoe.Symbol) (*tictactoe.GameState, error)
+	Profile(ctx context.Context, name string) (*tictactoe.Profile, error)
+	Leaderboard(ctx context.Context) ([]tictactoe.Profile, error)
+	Token(id tictactoe.GameID) string
+	SetToken(id tictactoe.GameID, token string)
+}
+
+// Option configures a client made with New.
+type Option func(*client)
+
+// WithHost sets the URL of the server, such as http://localhost:8080.
+func WithHost(host string) Option {
+	return func(c *client) {
+		c.host = strings.TrimSuffix(host, "/")
+	}
+}
+
+// WithHTTPClient sends the requests with hc rather than
+// http.DefaultClient. Event streams are cut off by hc.Timeout, so
+// prefer WithTimeout for limiting requests.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *client) {
+		c.http = hc
+	}
+}
+
+// WithDialer opens WebSockets with d rather than
+// websocket.DefaultDialer.
+func WithDialer(d *websocket.Dialer) Option {
+	return func(c *client) {
+		c.dialer = d
+	}
+}
+
+// WithTimeout limits each request answered right away, zero for no
+// limit.
+func WithTimeout(d time.Duration) Option {
+	return func(c *client) {
+		c.timeout = d
+	}
+}
+
+// WithPollTimeout limits long polls and matchmaking, zero for no
+// limit.
+func WithPollTimeout(d time.Duration) Option {
+	return func(c *client) {
+		c.pollTimeout = d
+	}
+}
+
+// WithAccount claims seats for a registered player, see Login.
+func WithAccount(name, key string) Option {
+	return func(c *client) {
+		c.Login(name, key)
+	}
+}
+
+// New makes a client for the server at DefaultHost unless an option
+// says otherwise.
+func New(opts ...Option) Client {
+	c := &client{
+		host:        DefaultHost,
+		http:        http.DefaultClient,
+		dialer:      websocket.DefaultDialer,
+		timeout:     DefaultTimeout,
+		pollTimeout: DefaultPollTimeout,
+		tokens:      map[tictactoe.GameID]string{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type client struct {
+	host        string
+	http        *http.Client
+	dialer      *websocket.Dialer
+	timeout     time.Duration
+	pollTimeout time.Duration
+
+	// mu guards the tokens and account, a client may be shared
+	// by goroutines
+	mu sync.Mutex
+	// tokens are the seat secrets handed out on create and join
+	tokens map[tictactoe.GameID]string
+	// name and key are the account seats are claimed for
+	name string
+	key  string
+}
+
+func (c *client) ListGames(ctx context.Context, filter tictactoe.GameFilter) (*tictactoe.GameList, error) {
+
+	query := neturl.Values{}
+	if filter.Status != "" {
+		query.Set("status", string(filter.Status))
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	if filter.Offset > 0 {
+		query.Set("offset", strconv.Itoa(filter.Offset))
+	}
+
+	games := &tictactoe.GameList{}
+	if err := c.request(ctx, http.MethodGet, "/?"+query.Encode(), nil, games); err != nil {
+		return nil, err
+	}
+
+	return games, nil
+}
+
+func (c *client) CreateGame(ctx context.Context, id tictactoe.GameID, symbol tictactoe.Symbol, opts tictactoe.GameOptions) (*tictactoe.JoinResponse, error) {
+
+	sym := "x"
+	if symbol == tictactoe.O {
+		sym = "o"
+	}
+
+	return c.seat(ctx, "/"+path(id)+"/create/"+sym+"?"+OptionsQuery(opts).Encode(), c.timeout)
+}
+
+func (c *client) JoinGame(ctx context.Context, id tictactoe.GameID) (*tictactoe.JoinResponse, error) {
+	return c.seat(ctx, "/"+path(id)+"/join", c.timeout)
+}
+
+// Matchmake waits in the server's queue until another player asks
+// for a game with the same options, asking again whenever the server
+// times out the wait.
+func (c *client) Matchmake(ctx context.Context, opts tictactoe.GameOptions) (*tictactoe.JoinResponse, error) {
+
+	for {
+		resp, err := c.seat(ctx, "/matchmake?"+OptionsQuery(opts).Encode(), c.pollTimeout)
+
+		var timeout *tictactoe.TimeoutErr
+		if errors.As(err, &timeout) && ctx.Err() == nil {
+			continue
+		}
+
+		return resp, err
+	}
+}
+
+// seat posts a request that hands out a seat and remembers its token.
+func (c *client) seat(ctx context.Context, url string, timeout time.Duration) (*tictactoe.JoinResponse, error) {
+
+	resp := &tictactoe.JoinResponse{}
+	if err := c.send(ctx, http.MethodPost, url, nil, timeout, resp); err != nil {
+		return nil, err
+	}
+
+	c.SetToken(resp.State.ID, resp.Token)
+
+	return resp, nil
+}
+
+// OptionsQuery encodes the game options as the query parameters
+// the server reads them from.
+func OptionsQuery(opts tictactoe.GameOptions) neturl.Values {
+
+	query := neturl.Values{}
+	if opts.AI != tictactoe.NoAI {
+		query.Set("ai", string(opts.AI))
+	}
+	if opts.Shape != (tictactoe.Shape{}) {
+		query.Set("width", strconv.Itoa(opts.Width))
+		query.Set("height", strconv.Itoa(opts.Height))
+		query.Set("win", strconv.Itoa(opts.WinLength))
+	}
+	if opts.BestOf > 0 {
+		query.Set("best_of", strconv.Itoa(opts.BestOf))
+	}
+	if opts.TimeControl.Base > 0 {
+		query.Set("time", strconv.Itoa(int(opts.TimeControl.Base.Seconds())))
+		query.Set("increment", strconv.Itoa(int(opts.TimeControl.Increment.Seconds())))
+	}
+	if opts.TimeControl.PerMove > 0 {
+		query.Set("per_move", strconv.Itoa(int(opts.TimeControl.PerMove.Seconds())))
+	}
+
+	return query
+}
+
+func (c *client) EndGame(ctx context.Context, id tictactoe.GameID) error {
+
+	header := http.Header{}
+	header.Set(tictactoe.TokenHeader, c.Token(id))
+
+	if err := c.request(ctx, http.MethodDelete, "/"+path(id)+"/end", header, nil); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	delete(c.tokens, id)
+	c.mu.Unlock()
+
+	return nil
+}
+
+// GetGame returns the current state of the game.
+func (c *client) GetGame(ctx context.Context, id tictactoe.GameID) (*tictactoe.GameState, error) {
+
+	// no board hashes to nothing, so the state comes right back
+	state := &tictactoe.GameState{}
+	if err := c.request(ctx, http.MethodGet, "/"+path(id)+"/-", nil, state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// Poll waits for the game to change from the board with the hash,
+// see tictactoe.Hash, and returns the new state. It returns the state
+// right away when the board has already changed, and a
+// *tictactoe.TimeoutErr when the server gave up waiting.
+func (c *client) Poll(ctx context.Context, id tictactoe.GameID, hash string) (*tictactoe.GameState, error) {
+
+	state := &tictactoe.GameState{}
+	err := c.send(ctx, http.MethodGet, "/"+path(id)+"/"+hash, nil, c.pollTimeout, state)
+
+	var status *statusErr
+	if errors.As(err, &status) && status.code == http.StatusGatewayTimeout {
+		return nil, &tictactoe.TimeoutErr{}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+func (c *client) Move(ctx context.Context, id tictactoe.GameID, symbol tictactoe.Symbol, index int) (*tictactoe.GameState, error) {
+	return c.play(ctx, id, "/"+path(id)+"/move/"+string(symbol)+"/"+strconv.Itoa(index))
+}
+
+func (c *client) Resign(ctx context.Context, id tictactoe.GameID, symbol tictactoe.Symbol) (*tictactoe.GameState, error) {
+	return c.play(ctx, id, "/"+path(id)+"/resign/"+string(symbol))
+}
+
+func (c *client) RequestTakeback(ctx context.Context, id tictactoe.GameID, symbol tictactoe.Symbol) (*tictactoe.GameState, error) {
+	return c.play(ctx, id, "/"+path(id)+"/takeback/"+string(symbol))
+}
+
+func (c *client) AnswerTakeback(ctx context.Context, id tictactoe.GameID, symbol tictactoe.Symbol, accept bool) (*tictactoe.GameState, error) {
+
+	answer := "decline"
+	if accept {
+		answer = "accept"
+	}
+
+	return c.play(ctx, id, "/"+path(id)+"/takeback/"+string(symbol)+"/"+answer)
+}
+
+func (c *client) Rematch(ctx context.Context, id tictactoe.GameID, symbol tictactoe.Symbol) (*tictactoe.GameState, error) {
+	return c.play(ctx, id, "/"+path(id)+"/rematch/"+string(symbol))
+}
+
+// play posts an action for our seat in the game and returns the
+// resulting state.
+func (c *client) play(ctx context.Context, id tictactoe.GameID, url string) (*tictactoe.GameState, error) {
+
+	header := http.Header{}
+	header.Set(tictactoe.TokenHeader, c.Token(id))
+
+	state := &tictactoe.GameState{}
+	if err := c.request(ctx, http.MethodPost, url, header, state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+func (c *client) History(ctx context.Context, id tictactoe.GameID) (*tictactoe.GameHistory, error) {
+
+	history := &tictactoe.GameHistory{}
+	if err := c.request(ctx, http.MethodGet, "/"+path(id)+"/history", nil, history); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// Analyze asks the server to solve the board, see tictactoe.Analyze.
+// The zero shape is the classic board and an Empty turn is worked out
+// from the board.
+func (c *client) Analyze(ctx context.Context, shape tictactoe.Shape, board []tictactoe.Symbol, turn tictactoe.Symbol) (*tictactoe.Analysis, error) {
+
+	query := OptionsQuery(tictactoe.GameOptions{Shape: shape})
+	if turn != tictactoe.Empty {
+		query.Set("turn", string(turn))
+	}
+
+	analysis := &tictactoe.Analysis{}
+	if err := c.request(ctx, http.MethodGet, "/analyze/"+tictactoe.Hash(board)+"?"+query.Encode(), nil, analysis); err != nil {
+		return nil, err
+	}
+
+	return analysis, nil
+}
+
+// Register creates an account and logs in to it.
+func (c *client) Register(ctx context.Context, name string) (*tictactoe.Account, error) {
+
+	account := &tictactoe.Account{}
+	if err := c.request(ctx, http.MethodPost, "/players/"+neturl.PathEscape(name), nil, account); err != nil {
+		return nil, err
+	}
+
+	c.Login(account.Name, account.Key)
+
+	return account, nil
+}
+
+// Login uses an existing account for the seats claimed from now on.
+func (c *client) Login(name, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.name = name
+	c.key = key
+}
+
+// Name is the account we are logged in to, empty if none.
+func (c *client) Name() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.name
+}
+
+// ClaimSeat puts our account's name on our seat in the game.
+func (c *client) ClaimSeat(ctx context.Context, id tictactoe.GameID, symbol tictactoe.Symbol) (*tictactoe.GameState, error) {
+
+	c.mu.Lock()
+	header := http.Header{}
+	header.Set(tictactoe.TokenHeader, c.tokens[id])
+	header.Set(tictactoe.NameHeader, c.name)
+	header.Set(tictactoe.KeyHeader, c.key)
+	c.mu.Unlock()
+
+	state := &tictactoe.GameState{}
+	if err := c.request(ctx, http.MethodPost, "/"+path(id)+"/claim/"+string(symbol), header, state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+func (c *client) Profile(ctx context.Context, name string) (*tictactoe.Profile, error) {
+
+	profile := &tictactoe.Profile{}
+	if err := c.request(ctx, http.MethodGet, "/players/"+neturl.PathEscape(name), nil, profile); err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+func (c *client) Leaderboard(ctx context.Context) ([]tictactoe.Profile, error) {
+
+	profiles := []tictactoe.Profile{}
+	if err := c.request(ctx, http.MethodGet, "/leaderboard", nil, &profiles); err != nil {
+		return nil, err
+	}
+
+	return profiles, nil
+}
+
+// Token is the secret of our seat in the game, empty if we have none.
+func (c *client) Token(id tictactoe.GameID) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.tokens[id]
+}
+
+// SetToken remembers the secret of our seat in the game, such as
+// one saved by a bot before it restarted.
+func (c *client) SetToken(id tictactoe.GameID, token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tokens[id] = token
+}
+
+// statusErr is a failed response without the JSON error envelope,
+// such as one from a proxy in front of the server.
+type statusErr struct {
+	code int
+	body string
+}
+
+func (e *statusErr) Error() string {
+	if e.body == "" {
+		return http.StatusText(e.code)
+	}
+	return e.body
+}
+
+// decodeError turns an error response back into the error the
+// server returned, so callers can check for it with errors.As.
+func decodeError(code int, body []byte) error {
+
+	resp := tictactoe.ErrorResponse{}
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Code == "" {
+		return &statusErr{code: code, body: strings.TrimSpace(string(body))}
+	}
+
+	return tictactoe.ErrorFromCode(resp.Code, resp.Message)
+}
+
+// request sends a request answered right away, see send.
+func (c *client) request(ctx context.Context, method, url string, header http.Header, out interface{}) error {
+	return c.send(ctx, method, url, header, c.timeout, out)
+}
+
+// send sends a request to the server within timeout and decodes the
+// JSON response into out, unless out is nil. Error responses are
+// decoded with decodeError.
+func (c *client) send(ctx context.Context, method, url string, header http.Header, timeout time.Duration, out interface{}) error {
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequest(method, c.host+url, new(bytes.Buffer))
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return decodeError(resp.StatusCode, respBody)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return errors.New("could not decode response")
+	}
+
+	return nil
+}
+
+// path escapes a game ID for use in a URL.
+func path(id tictactoe.GameID) string {
+	return neturl.PathEscape(string(id))
+}
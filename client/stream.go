@@ -0,0 +1,312 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/svolpe43/ttt/server/tictactoe"
+)
+
+// Stream is a subscription to the states of a game over Server-Sent
+// Events, see Subscribe.
+type Stream struct {
+	states chan tictactoe.GameState
+	body   io.ReadCloser
+	cancel context.CancelFunc
+
+	// mu guards err, seq and ended
+	mu  sync.Mutex
+	err error
+	// seq is the sequence number of the last state received, the
+	// stream resumes after it
+	seq uint64
+	// ended is set once the game's final state is received
+	ended bool
+}
+
+// Subscribe streams every state published for the game, starting
+// with its current state. The states channel is closed when the game
+// ends, the context is done or the connection is lost, after which
+// Err says why.
+func (c *client) Subscribe(ctx context.Context, id tictactoe.GameID) (*Stream, error) {
+	return c.subscribe(ctx, id, 0)
+}
+
+// subscribe opens an event stream of the game, replaying the states
+// after seq unless it is zero.
+func (c *client) subscribe(ctx context.Context, id tictactoe.GameID, seq uint64) (*Stream, error) {
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	req, err := http.NewRequest(http.MethodGet, c.host+"/"+path(id)+"/events", nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if seq > 0 {
+		req.Header.Set("Last-Event-ID", strconv.FormatUint(seq, 10))
+	}
+
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		cancel()
+		return nil, decodeError(resp.StatusCode, respBody)
+	}
+
+	s := &Stream{
+		states: make(chan tictactoe.GameState),
+		body:   resp.Body,
+		cancel: cancel,
+		seq:    seq,
+	}
+	go s.read(ctx)
+
+	return s, nil
+}
+
+// States receives the states of the game in the order they were
+// published.
+func (s *Stream) States() <-chan tictactoe.GameState {
+	return s.states
+}
+
+// Err is why the stream stopped, nil while it is open or when the
+// game ended. A server shutting down gives a
+// *tictactoe.ShuttingDownErr.
+func (s *Stream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close stops the stream.
+func (s *Stream) Close() error {
+	s.cancel()
+	return nil
+}
+
+func (s *Stream) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// last is the sequence number of the last state received.
+func (s *Stream) last() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seq
+}
+
+// read parses the events off the response until it ends.
+func (s *Stream) read(ctx context.Context) {
+
+	defer close(s.states)
+	defer s.body.Close()
+	defer s.cancel()
+
+	scanner := bufio.NewScanner(s.body)
+	// a state holds the whole board, which may be large
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var event, data string
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// a blank line dispatches the event
+		if line == "" {
+			if data != "" && !s.dispatch(ctx, event, data) {
+				return
+			}
+			event, data = "", ""
+			continue
+		}
+
+		// lines starting with a colon are keep-alive comments
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			if data != "" {
+				data += "\n"
+			}
+			data += value
+		}
+	}
+
+	if ctx.Err() != nil {
+		s.fail(ctx.Err())
+		return
+	}
+	if err := scanner.Err(); err != nil {
+		s.fail(err)
+		return
+	}
+
+	// the server also closes streams that fall behind
+	s.mu.Lock()
+	ended := s.ended
+	s.mu.Unlock()
+	if !ended {
+		s.fail(io.ErrUnexpectedEOF)
+	}
+}
+
+// dispatch handles one event, reporting whether to keep reading.
+func (s *Stream) dispatch(ctx context.Context, event, data string) bool {
+
+	if event == "shutdown" {
+		resp := tictactoe.ErrorResponse{}
+		if err := json.Unmarshal([]byte(data), &resp); err != nil {
+			s.fail(&tictactoe.ShuttingDownErr{})
+		} else {
+			s.fail(tictactoe.ErrorFromCode(resp.Code, resp.Message))
+		}
+		return false
+	}
+
+	state := tictactoe.GameState{}
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		s.fail(errors.New("could not decode state"))
+		return false
+	}
+
+	s.mu.Lock()
+	s.seq = state.Seq
+	s.ended = state.Event == tictactoe.EndedEvent
+	s.mu.Unlock()
+
+	select {
+	case s.states <- state:
+		return true
+	case <-ctx.Done():
+		s.fail(ctx.Err())
+		return false
+	}
+}
+
+// Follow calls fn with every state published for the game, starting
+// with its current state, until the game ends, fn returns an error or
+// the context is done. Lost connections are resumed where they left
+// off, so no state is missed or repeated.
+func (c *client) Follow(ctx context.Context, id tictactoe.GameID, fn func(tictactoe.GameState) error) error {
+
+	var seq uint64
+
+	for {
+		stream, err := c.subscribe(ctx, id, seq)
+		if err != nil {
+			return err
+		}
+
+		for state := range stream.States() {
+			if err := fn(state); err != nil {
+				stream.Close()
+				// let the reader finish before returning
+				for range stream.States() {
+				}
+				return err
+			}
+		}
+
+		err = stream.Err()
+		if err == nil {
+			// the game ended
+			return nil
+		}
+
+		var shutdown *tictactoe.ShuttingDownErr
+		if ctx.Err() != nil || errors.As(err, &shutdown) {
+			return err
+		}
+
+		// the connection dropped, pick up after the last state
+		seq = stream.last()
+	}
+}
+
+// Socket is a live connection to a game played over a WebSocket.
+type Socket interface {
+	Send(msg tictactoe.ClientMessage) error
+	Receive() (*tictactoe.ServerMessage, error)
+	Close() error
+}
+
+// Connect opens a WebSocket to the game for the given seat.
+func (c *client) Connect(ctx context.Context, id tictactoe.GameID, symbol tictactoe.Symbol) (Socket, error) {
+
+	// http becomes ws and https becomes wss
+	url := "ws" + strings.TrimPrefix(c.host, "http") + "/" + path(id) + "/ws?symbol=" + string(symbol)
+
+	header := http.Header{}
+	header.Set(tictactoe.TokenHeader, c.Token(id))
+
+	conn, _, err := c.dialer.DialContext(ctx, url, header)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wsSocket{conn: conn}, nil
+}
+
+// Watch opens a read only WebSocket to the game as a spectator.
+func (c *client) Watch(ctx context.Context, id tictactoe.GameID) (Socket, error) {
+
+	url := "ws" + strings.TrimPrefix(c.host, "http") + "/" + path(id) + "/watch"
+
+	conn, _, err := c.dialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wsSocket{conn: conn}, nil
+}
+
+type wsSocket struct {
+	conn *websocket.Conn
+}
+
+func (s *wsSocket) Send(msg tictactoe.ClientMessage) error {
+	return s.conn.WriteJSON(msg)
+}
+
+func (s *wsSocket) Receive() (*tictactoe.ServerMessage, error) {
+	msg := &tictactoe.ServerMessage{}
+	if err := s.conn.ReadJSON(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (s *wsSocket) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/svolpe43/ttt/client"
+)
+
+// The defaults of the client settings.
+const (
+	DefaultTimeout = 10 * time.Second
+	// DefaultPollTimeout outlasts the server's long polls and
+	// matchmaking waits, which answer on their own before it runs out
+	DefaultPollTimeout = 90 * time.Second
+	DefaultRetries     = 8
+	DefaultBackoffBase = 500 * time.Millisecond
+	DefaultBackoffMax  = 30 * time.Second
+)
+
+// EnvPrefix starts the name of every environment variable the client
+// reads its settings from, such as TTT_SERVER.
+const EnvPrefix = "TTT_"
+
+// ClientConfig is how the client reaches the server. Each setting is
+// read from a command line flag, then an environment variable,
+// falling back to its default.
+type ClientConfig struct {
+	// Host is the URL of the server, such as http://localhost:8080
+	Host string
+	// Timeout limits each request, PollTimeout the requests that
+	// wait on the server such as long polls
+	Timeout     time.Duration
+	PollTimeout time.Duration
+	// Retry is how lost connections are retried
+	Retry Backoff
+}
+
+// DefaultClientConfig returns the settings used when nothing is set.
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{
+		Host:        client.DefaultHost,
+		Timeout:     DefaultTimeout,
+		PollTimeout: DefaultPollTimeout,
+		Retry: Backoff{
+			Base:       DefaultBackoffBase,
+			Max:        DefaultBackoffMax,
+			MaxRetries: DefaultRetries,
+		},
+	}
+}
+
+// Client and Socket are the SDK's, see package client.
+type (
+	Client = client.Client
+	Socket = client.Socket
+)
+
+// NewClient makes a client for the configured server.
+func NewClient(config ClientConfig) Client {
+	return client.New(
+		client.WithHost(config.Host),
+		client.WithTimeout(config.Timeout),
+		client.WithPollTimeout(config.PollTimeout),
+		client.WithDialer(&websocket.Dialer{HandshakeTimeout: config.Timeout}),
+	)
+}
+
+// LoadClientConfig reads the config from the command line arguments
+// and the environment read with getenv.
+func LoadClientConfig(args []string, getenv func(string) string) (ClientConfig, error) {
+
+	config := DefaultClientConfig()
+
+	fs := flag.NewFlagSet("frontend", flag.ContinueOnError)
+	host := fs.String("server", env(getenv, "server", config.Host), "URL of the server (env TTT_SERVER)")
+	timeout := fs.String("timeout", env(getenv, "timeout", config.Timeout.String()), "longest a request may take (env TTT_TIMEOUT)")
+	pollTimeout := fs.String("poll-timeout", env(getenv, "poll-timeout", config.PollTimeout.String()), "longest a long poll may take (env TTT_POLL_TIMEOUT)")
+	retries := fs.String("retries", env(getenv, "retries", strconv.Itoa(config.Retry.MaxRetries)), "times to retry a lost connection before giving up (env TTT_RETRIES)")
+
+	if err := fs.Parse(args); err != nil {
+		return config, err
+	}
+
+	config.Host = strings.TrimSuffix(*host, "/")
+	if !strings.HasPrefix(config.Host, "http://") && !strings.HasPrefix(config.Host, "https://") {
+		return config, errors.New("server must start with http:// or https://")
+	}
+
+	for _, d := range []struct {
+		name  string
+		value string
+		out   *time.Duration
+	}{
+		{"timeout", *timeout, &config.Timeout},
+		{"poll-timeout", *pollTimeout, &config.PollTimeout},
+	} {
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil || parsed <= 0 {
+			return config, fmt.Errorf("%s must be a duration such as 10s", d.name)
+		}
+		*d.out = parsed
+	}
+
+	n, err := strconv.Atoi(*retries)
+	if err != nil || n < 0 {
+		return config, errors.New("retries must be a number")
+	}
+	config.Retry.MaxRetries = n
+
+	return config, nil
+}
+
+// env returns the environment variable of a setting, such as
+// TTT_POLL_TIMEOUT for poll-timeout, or def when it is not set.
+func env(getenv func(string) string, name, def string) string {
+	if value := getenv(EnvPrefix + strings.ToUpper(strings.Replace(name, "-", "_", -1))); value != "" {
+		return value
+	}
+	return def
+}
+
+// Backoff spaces out retries so a struggling server is not swamped
+// by every client at once. Delays double from Base up to Max, each
+// picked at random from its upper half.
+type Backoff struct {
+	Base       time.Duration
+	Max        time.Duration
+	MaxRetries int
+}
+
+// Delay is how long to wait before the retry after attempt failed,
+// counting attempts from zero.
+func (b Backoff) Delay(attempt int) time.Duration {
+
+	// stops at Max without doubling past it, which could overflow
+	d := b.Base
+	for i := 0; i < attempt && d > 0 && d < b.Max; i++ {
+		if d > b.Max/2 {
+			d = b.Max
+			break
+		}
+		d *= 2
+	}
+	if d > b.Max {
+		d = b.Max
+	}
+	if d <= 0 {
+		return 0
+	}
+
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
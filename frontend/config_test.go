@@ -0,0 +1,115 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadClientConfigPrecedence(t *testing.T) {
+
+	for _, tc := range []struct {
+		name string
+		env  string
+		flag string
+		want time.Duration
+	}{
+		{"default", "", "", DefaultTimeout},
+		{"env over default", "20s", "", 20 * time.Second},
+		{"flag over env", "20s", "5s", 5 * time.Second},
+		{"flag over default", "", "5s", 5 * time.Second},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+
+			env := map[string]string{"TTT_TIMEOUT": tc.env}
+			args := []string{}
+			if tc.flag != "" {
+				args = append(args, "-timeout", tc.flag)
+			}
+
+			config, err := LoadClientConfig(args, func(name string) string {
+				return env[name]
+			})
+			require.NoError(t, err)
+			require.Equal(t, tc.want, config.Timeout)
+
+			// settings given nowhere keep their defaults
+			require.Equal(t, DefaultPollTimeout, config.PollTimeout)
+			require.Equal(t, DefaultRetries, config.Retry.MaxRetries)
+		})
+	}
+
+	// dashes in flag names are underscores in the environment
+	config, err := LoadClientConfig([]string{"-retries", "2"}, func(name string) string {
+		return map[string]string{
+			"TTT_SERVER":       "https://example.com/",
+			"TTT_POLL_TIMEOUT": "2m",
+			"TTT_RETRIES":      "5",
+		}[name]
+	})
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com", config.Host)
+	require.Equal(t, 2*time.Minute, config.PollTimeout)
+	require.Equal(t, 2, config.Retry.MaxRetries)
+}
+
+func TestLoadClientConfigInvalid(t *testing.T) {
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		env  map[string]string
+	}{
+		{"unknown flag", []string{"-colour", "blue"}, nil},
+		{"server without scheme", []string{"-server", "localhost:8080"}, nil},
+		{"bad server env", nil, map[string]string{"TTT_SERVER": "ftp://localhost"}},
+		{"bad duration flag", []string{"-timeout", "soon"}, nil},
+		{"bad duration env", nil, map[string]string{"TTT_POLL_TIMEOUT": "later"}},
+		{"zero timeout", []string{"-timeout", "0s"}, nil},
+		{"negative poll timeout", []string{"-poll-timeout", "-1s"}, nil},
+		{"bad retries", []string{"-retries", "many"}, nil},
+		{"negative retries env", nil, map[string]string{"TTT_RETRIES": "-1"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadClientConfig(tc.args, func(name string) string {
+				return tc.env[name]
+			})
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+
+	b := Backoff{Base: time.Second, Max: 10 * time.Second}
+
+	for _, tc := range []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 8 * time.Second},
+		{4, 10 * time.Second},
+		{100, 10 * time.Second},
+		{math.MaxInt32, 10 * time.Second},
+	} {
+		// each delay is picked from the upper half of the step
+		for i := 0; i < 20; i++ {
+			d := b.Delay(tc.attempt)
+			require.True(t, d >= tc.want/2 && d <= tc.want, "attempt %d waited %s", tc.attempt, d)
+		}
+	}
+
+	// doubling up to a cap near the largest duration does not overflow
+	huge := Backoff{Base: time.Second, Max: math.MaxInt64}
+	for _, attempt := range []int{62, 63, 64, 1000, math.MaxInt64} {
+		require.True(t, huge.Delay(attempt) > 0, "attempt %d", attempt)
+	}
+
+	// nothing to wait without a base
+	require.Zero(t, Backoff{Max: time.Second}.Delay(math.MaxInt64))
+}
@@ -3,235 +3,1465 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"net/http"
+	"math/rand"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"text/tabwriter"
 	"time"
 
 	"github.com/svolpe43/ttt/server/tictactoe"
 )
 
+// game is the game being played or watched, nil when there is none.
 var game *Game
 
+// socket is set while the game is played over a WebSocket,
+// otherwise the game falls back to long polling.
+var socket Socket
+
+// session guards game and socket, and the Game game points to, which
+// the prompt shares with the goroutines that listen, poll and
+// reconnect. It is not held while waiting on the server.
+var session sync.Mutex
+
+// retry is how lost connections to the game are retried.
+var retry Backoff
+
 type Game struct {
 	id     tictactoe.GameID
+	shape  tictactoe.Shape
 	board  []tictactoe.Symbol
 	symbol tictactoe.Symbol
 	turn   tictactoe.Symbol
+	series tictactoe.Series
+	// spectators is the number of people watching
+	spectators int
+	// players names the seats claimed by registered players
+	players map[tictactoe.Symbol]string
+	// clock is the time left when the last state was received,
+	// nil for untimed games
+	clock    *tictactoe.Clock
+	received time.Time
+	// waiting is set until an opponent takes the other seat
+	waiting bool
+	// over is set once the game has a result, the game is kept
+	// around so the players can ask for a rematch
+	over bool
+}
+
+// playing reports whether we hold a seat in a game still being played.
+func (g *Game) playing() bool {
+	return g != nil && g.symbol != tictactoe.Empty && !g.over
+}
+
+// update takes the board and score from a state of the game.
+func (g *Game) update(state *tictactoe.GameState) {
+	g.shape = state.Shape
+	g.board = state.Board
+	g.turn = state.Turn
+	g.series = state.Series
+	g.spectators = state.Spectators
+	g.players = state.Players
+	g.clock = state.Clock
+	g.received = time.Now()
+	g.waiting = state.Status == tictactoe.Waiting
+	g.over = state.Status.Over()
+
+	running.Lock()
+	running.clock = nil
+	if !g.over && g.clock != nil && g.clock.Running == g.symbol && g.turn == g.symbol {
+		running.clock = g.clock
+		running.received = g.received
+	}
+	running.Unlock()
+}
+
+// running is our clock while it is running, set by every state
+// received and read by countdown from its own goroutine.
+var running struct {
+	sync.Mutex
+	clock    *tictactoe.Clock
+	received time.Time
 }
 
 func main() {
 
+	config, err := LoadClientConfig(os.Args[1:], os.Getenv)
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+
+	rand.Seed(time.Now().UnixNano())
+	retry = config.Retry
+
 	var (
 		ctx    = context.Background()
-		client = NewClient()
+		client = NewClient(config)
 		reader = bufio.NewReader(os.Stdin)
 	)
 
 	fmt.Println("Welcome to Tic Tak Toe!")
 
+	go countdown()
+
 	for {
 		fmt.Print("-> ")
 		text, _ := reader.ReadString('\n')
 		text = strings.Replace(text, "\n", "", -1)
 		args := strings.Split(text, " ")
 
-		switch args[0] {
-		case "list":
-			if len(args) != 1 {
-				fmt.Println("Usage: list")
-				continue
-			}
+		command(ctx, client, args)
+	}
+}
 
-			games, err := client.ListGames(ctx)
-			if err != nil {
-				fmt.Println(err)
-				continue
-			}
-			fmt.Println(games)
+// command runs a line typed at the prompt.
+func command(ctx context.Context, client Client, args []string) {
 
-		case "create":
+	session.Lock()
+	defer session.Unlock()
 
-			if game != nil {
-				fmt.Println("There is already a game in progress, first end the game.")
-				continue
-			}
+	switch args[0] {
+	case "list":
+		filter, err := parseListFlags(args[1:])
+		if err != nil {
+			fmt.Println(err)
+			fmt.Println(listUsage)
+			return
+		}
 
-			if len(args) != 3 {
-				fmt.Println("Usage: create <game name> <piece>")
-				continue
-			}
+		games, err := client.ListGames(ctx, filter)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
 
-			symbol := tictactoe.X
-			if args[2] == "O" {
-				symbol = tictactoe.O
-			}
+		list(games, filter)
 
-			if err := client.CreateGame(ctx, args[1], symbol); err != nil {
-				fmt.Println(err)
-				continue
-			}
+	case "create":
 
-			game = &Game{
-				id:     tictactoe.GameID(args[1]),
-				board:  make([]tictactoe.Symbol, 9),
-				symbol: symbol,
-				turn:   symbol,
-			}
+		if game.playing() {
+			fmt.Println("There is already a game in progress, first end the game.")
+			return
+		}
 
-			render(game)
+		if len(args) < 3 {
+			fmt.Println(createUsage)
+			return
+		}
+
+		symbol := tictactoe.X
+		if args[2] == "O" {
+			symbol = tictactoe.O
+		}
+
+		opts, err := parseCreateFlags(args[3:])
+		if err != nil {
+			fmt.Println(err)
+			fmt.Println(createUsage)
+			return
+		}
+
+		resp, err := client.CreateGame(ctx, tictactoe.GameID(args[1]), symbol, opts)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		// leave the finished game behind
+		disconnect()
+
+		game = &Game{
+			id:     resp.State.ID,
+			shape:  resp.State.Shape,
+			symbol: resp.Symbol,
+		}
+		game.update(&resp.State)
+		claim(ctx, client)
+
+		render(game)
+
+		// the states arrive on the socket or the long poll, the
+		// first once an opponent joins
+		if game.waiting {
+			fmt.Println()
+			fmt.Printf("Waiting for an opponent to join game \"%s\"\n", game.id)
+			fmt.Println()
+		} else if game.turn == game.symbol {
 			fmt.Println()
 			fmt.Println("Your turn!")
 			fmt.Println()
+		}
 
-		case "join":
+		if !connect(ctx, client) {
+			go longPoll(ctx, client, game)
+		}
 
-			if game != nil {
-				fmt.Println("You are already connected to a game")
-				continue
-			}
+	case "join":
 
-			if len(args) != 2 {
-				fmt.Println("Usage: join <game name>")
-				continue
-			}
+		if game.playing() {
+			fmt.Println("You are already connected to a game")
+			return
+		}
 
-			resp, err := client.JoinGame(ctx, args[1])
-			if err != nil {
-				fmt.Println(err)
-				continue
-			}
+		if len(args) != 2 {
+			fmt.Println("Usage: join <game name>")
+			return
+		}
 
-			game = &Game{
-				id:     resp.State.ID,
-				board:  resp.State.Board,
-				symbol: resp.Symbol,
-				turn:   resp.State.Turn,
-			}
+		resp, err := client.JoinGame(ctx, tictactoe.GameID(args[1]))
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
 
-			render(game)
+		// leave the finished game behind
+		disconnect()
 
-			go longPoll(ctx, client)
+		game = &Game{
+			id:     resp.State.ID,
+			shape:  resp.State.Shape,
+			symbol: resp.Symbol,
+		}
+		game.update(&resp.State)
+		claim(ctx, client)
 
-		case "end":
-			if len(args) != 2 {
-				fmt.Println("Usage: end <game name>")
-				continue
-			}
+		render(game)
+
+		if !connect(ctx, client) {
+			go longPoll(ctx, client, game)
+		}
+
+	case "play":
+
+		if game.playing() {
+			fmt.Println("There is already a game in progress, first end the game.")
+			return
+		}
+
+		opts, err := parseCreateFlags(args[1:])
+		if err != nil {
+			fmt.Println(err)
+			fmt.Println(playUsage)
+			return
+		}
+
+		fmt.Println("Looking for an opponent...")
+
+		// a finished game left behind still hears of rematches
+		// while we wait
+		session.Unlock()
+		resp, err := client.Matchmake(ctx, opts)
+		session.Lock()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		disconnect()
+
+		game = &Game{
+			id:     resp.State.ID,
+			shape:  resp.State.Shape,
+			symbol: resp.Symbol,
+		}
+		game.update(&resp.State)
+		claim(ctx, client)
+
+		render(game)
+
+		if game.turn == game.symbol {
+			fmt.Println()
+			fmt.Println("Your turn!")
+			fmt.Println()
+			connect(ctx, client)
+			return
+		}
+
+		if !connect(ctx, client) {
+			go longPoll(ctx, client, game)
+		}
+
+	case "watch":
+		if len(args) != 2 {
+			fmt.Println("Usage: watch <game name>")
+			return
+		}
+
+		if game.playing() {
+			fmt.Println("You are already connected to a game")
+			return
+		}
+
+		s, err := client.Watch(ctx, tictactoe.GameID(args[1]))
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		disconnect()
+
+		// the board is rendered once the first state arrives
+		game = &Game{
+			id: tictactoe.GameID(args[1]),
+		}
+		socket = s
+		go listen(ctx, client, s)
+
+	case "reconnect":
+		if game == nil {
+			fmt.Println("There is no game to reconnect to")
+			return
+		}
+
+		disconnect()
+
+		if connect(ctx, client) {
+			fmt.Println("Reconnected")
+			return
+		}
+
+		// a spectator has nothing to fall back on
+		if game.symbol == tictactoe.Empty {
+			fmt.Println("Could not reach the server, try again later")
+			return
+		}
+
+		go longPoll(ctx, client, game)
+
+	case "end":
+		if len(args) != 2 {
+			fmt.Println("Usage: end <game name>")
+			return
+		}
+
+		if err := client.EndGame(ctx, tictactoe.GameID(args[1])); err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		game = nil
+		disconnect()
+
+		fmt.Println("Ended game", args[1])
+
+	case "move":
+		if len(args) != 2 {
+			fmt.Println("Usage: move <index|cell>")
+			return
+		}
+
+		if game == nil {
+			fmt.Println("You must create or join a game first")
+			return
+		}
 
-			if err := client.EndGame(ctx, args[1]); err != nil {
+		if game.waiting {
+			fmt.Println("Waiting for an opponent to join")
+			return
+		}
+
+		index, err := parseCell(args[1], game.shape)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		// the resulting state arrives on the socket
+		if socket != nil {
+			if err := socket.Send(tictactoe.ClientMessage{
+				Type:  tictactoe.MoveMessage,
+				Index: int(index),
+			}); err != nil {
 				fmt.Println(err)
-				continue
 			}
+			return
+		}
 
-			game = nil
+		state, err := client.Move(ctx, game.id, game.symbol, int(index))
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
 
-			fmt.Println("Ended game", args[1])
+		game.update(state)
 
-		case "move":
-			if len(args) != 2 {
-				fmt.Println("Usage: move <index>")
-				continue
-			}
+		render(game)
 
-			if game == nil {
-				fmt.Println("You must create or join a game first")
-				continue
-			}
+		if gameOver(state) {
+			return
+		}
 
-			index, err := strconv.ParseInt(args[1], 10, 0)
-			if err != nil {
-				fmt.Println("Cannot parse index")
-				continue
-			}
+		// the computer has already replied
+		if game.turn == game.symbol {
+			fmt.Println()
+			fmt.Println("Your turn!")
+			fmt.Println()
+			return
+		}
 
-			state, err := client.Move(ctx, game.id, game.symbol, int(index))
-			if err != nil {
+		go longPoll(ctx, client, game)
+
+	case "resign":
+		if len(args) != 1 {
+			fmt.Println("Usage: resign")
+			return
+		}
+
+		if game == nil {
+			fmt.Println("You must create or join a game first")
+			return
+		}
+
+		if socket != nil {
+			if err := socket.Send(tictactoe.ClientMessage{
+				Type: tictactoe.ResignMessage,
+			}); err != nil {
 				fmt.Println(err)
-				continue
 			}
+			return
+		}
 
-			game.board = state.Board
-			game.turn = state.Turn
+		state, err := client.Resign(ctx, game.id, game.symbol)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
 
-			if state.Winner != tictactoe.Empty {
-				render(game)
-				game = nil
-				fmt.Println()
-				fmt.Println("Winner!", state.Winner)
-				continue
+		game.update(state)
+		gameOver(state)
+
+	case "replay":
+		if len(args) != 2 {
+			fmt.Println("Usage: replay <game name>")
+			return
+		}
+
+		history, err := client.History(ctx, tictactoe.GameID(args[1]))
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		// the game is left alone while the moves are played back
+		session.Unlock()
+		replay(history)
+		session.Lock()
+
+	case "hint":
+		if len(args) != 1 {
+			fmt.Println("Usage: hint")
+			return
+		}
+
+		if game == nil {
+			fmt.Println("You must create or join a game first")
+			return
+		}
+
+		if game.over {
+			fmt.Println("The game is over")
+			return
+		}
+
+		analysis, err := client.Analyze(ctx, game.shape, game.board, game.turn)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		hint(analysis)
+
+	case "undo":
+		if len(args) != 1 {
+			fmt.Println("Usage: undo")
+			return
+		}
+
+		if game == nil {
+			fmt.Println("You must create or join a game first")
+			return
+		}
+
+		if socket != nil {
+			if err := socket.Send(tictactoe.ClientMessage{
+				Type: tictactoe.TakebackMessage,
+			}); err != nil {
+				fmt.Println(err)
 			}
+			return
+		}
+
+		state, err := client.RequestTakeback(ctx, game.id, game.symbol)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
 
+		// a computer opponent agrees right away, otherwise
+		// the answer arrives on the long poll
+		if !takebackNotice(state) {
+			game.update(state)
 			render(game)
+		}
 
-			go longPoll(ctx, client)
+	case "accept", "decline":
+		if len(args) != 1 {
+			fmt.Printf("Usage: %s\n", args[0])
+			return
+		}
 
-		default:
-			fmt.Println("Unknown command")
+		if game == nil {
+			fmt.Println("You must create or join a game first")
+			return
 		}
-	}
-}
 
-func longPoll(ctx context.Context, client Client) {
+		accept := args[0] == "accept"
 
-	for {
+		if socket != nil {
+			msg := tictactoe.ClientMessage{Type: tictactoe.DeclineMessage}
+			if accept {
+				msg.Type = tictactoe.AcceptMessage
+			}
+			if err := socket.Send(msg); err != nil {
+				fmt.Println(err)
+			}
+			return
+		}
 
-		state, code, err := client.GetGame(ctx, string(game.id), tictactoe.Hash(game.board))
+		state, err := client.AnswerTakeback(ctx, game.id, game.symbol, accept)
 		if err != nil {
 			fmt.Println(err)
-			time.Sleep(time.Second * 3)
-			continue
+			return
 		}
 
-		if code == http.StatusGatewayTimeout || code == http.StatusRequestTimeout {
-			time.Sleep(time.Second * 3)
-			continue
+		if takebackNotice(state) {
+			return
 		}
 
-		if code != http.StatusOK {
-			time.Sleep(time.Second * 3)
-			continue
+		// the opponent gets the turn back
+		game.update(state)
+		render(game)
+
+		go longPoll(ctx, client, game)
+
+	case "rematch":
+		if len(args) != 1 {
+			fmt.Println("Usage: rematch")
+			return
+		}
+
+		if game == nil {
+			fmt.Println("You must create or join a game first")
+			return
+		}
+
+		if socket != nil {
+			if err := socket.Send(tictactoe.ClientMessage{
+				Type: tictactoe.RematchMessage,
+			}); err != nil {
+				fmt.Println(err)
+			}
+			return
+		}
+
+		state, err := client.Rematch(ctx, game.id, game.symbol)
+		if err != nil {
+			fmt.Println(err)
+			return
 		}
 
-		game.board = state.Board
-		game.turn = state.Turn
+		// wait on the long poll for the opponent to agree
+		if rematchNotice(state) {
+			go longPoll(ctx, client, game)
+			return
+		}
 
+		game.update(state)
 		render(game)
 
-		if state.Winner != tictactoe.Empty {
-			fmt.Println()
-			fmt.Println("Winner!", state.Winner)
-			game = nil
-			break
+		if game.turn != game.symbol {
+			go longPoll(ctx, client, game)
+			return
 		}
 
 		fmt.Println()
 		fmt.Println("Your turn!")
 		fmt.Println()
-		fmt.Print("-> ")
-		break
-	}
-}
 
-func render(game *Game) {
-	if len(game.board) == 9 {
+	case "login":
+		if len(args) < 2 || len(args) > 3 {
+			fmt.Println("Usage: login <name> [key]")
+			return
+		}
+
+		if len(args) == 3 {
+			if _, err := client.Profile(ctx, args[1]); err != nil {
+				fmt.Println(err)
+				return
+			}
+			client.Login(args[1], args[2])
+			fmt.Println("Logged in as", args[1])
+			return
+		}
 
-		b := []string{}
-		for i := range game.board {
-			b = append(b, empty(game.board[i]))
+		account, err := client.Register(ctx, args[1])
+		if err != nil {
+			fmt.Println(err)
+			return
 		}
 
-		fmt.Println()
-		fmt.Printf("Playing game \"%s\" as %s\n", game.id, game.symbol)
-		fmt.Printf("Turn: %s\n", game.turn)
-		fmt.Printf(" %s | %s | %s \n", b[0], b[1], b[2])
-		fmt.Println("-----------")
-		fmt.Printf(" %s | %s | %s \n", b[3], b[4], b[5])
-		fmt.Println("-----------")
-		fmt.Printf(" %s | %s | %s \n", b[6], b[7], b[8])
+		fmt.Println("Registered", account.Name)
+		fmt.Printf("Log in next time with: login %s %s\n", account.Name, account.Key)
+
+	case "stats":
+		if len(args) > 2 {
+			fmt.Println("Usage: stats [name]")
+			return
+		}
+
+		name := client.Name()
+		if len(args) == 2 {
+			name = args[1]
+		}
+		if name == "" {
+			fmt.Println("Usage: stats [name], or log in first")
+			return
+		}
+
+		profile, err := client.Profile(ctx, name)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		fmt.Printf("%s: rating %d, %d won, %d lost, %d drawn\n",
+			profile.Name, profile.Rating, profile.Wins, profile.Losses, profile.Draws)
+
+	case "leaderboard":
+		if len(args) != 1 {
+			fmt.Println("Usage: leaderboard")
+			return
+		}
+
+		profiles, err := client.Leaderboard(ctx)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "#\tNAME\tRATING\tWON\tLOST\tDRAWN")
+		for i, p := range profiles {
+			fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%d\t%d\n", i+1, p.Name, p.Rating, p.Wins, p.Losses, p.Draws)
+		}
+		w.Flush()
+
+	case "say":
+		if len(args) < 2 {
+			fmt.Println("Usage: say <message>")
+			return
+		}
+
+		if socket == nil {
+			fmt.Println("Chat needs a live connection to a game")
+			return
+		}
+
+		if err := socket.Send(tictactoe.ClientMessage{
+			Type: tictactoe.ChatMessage,
+			Text: strings.Join(args[1:], " "),
+		}); err != nil {
+			fmt.Println(err)
+		}
+
+	default:
+		fmt.Println("Unknown command")
+	}
+}
+
+// claim puts our account's name on our seat so the game is rated,
+// when we are logged in. The session lock must be held.
+func claim(ctx context.Context, client Client) {
+
+	if client.Name() == "" {
+		return
+	}
+
+	state, err := client.ClaimSeat(ctx, game.id, game.symbol)
+	if err != nil {
+		fmt.Println("This game is not rated:", err)
+		return
+	}
+
+	game.update(state)
+}
+
+// longPoll renders the states of g until it is our turn or the game
+// is over. It gives up once another game is started, or once the
+// server cannot be reached after retry.MaxRetries tries.
+func longPoll(ctx context.Context, client Client, g *Game) {
+
+	attempt := 0
+
+	for {
+		session.Lock()
+		current, hash := game == g, tictactoe.Hash(g.board)
+		session.Unlock()
+		if !current {
+			return
+		}
+
+		state, err := client.Poll(ctx, g.id, hash)
+
+		// nothing happened while the server waited
+		var timeout *tictactoe.TimeoutErr
+		waited := errors.As(err, &timeout)
+		if waited {
+			err = nil
+		}
+
+		// there is nothing left to wait for
+		var notFound *tictactoe.GameNotFoundErr
+		if errors.As(err, &notFound) {
+			fmt.Println()
+			fmt.Println("Game ended")
+			fmt.Print("-> ")
+			session.Lock()
+			if game == g {
+				game = nil
+			}
+			session.Unlock()
+			return
+		}
+
+		if err != nil {
+			if attempt == retry.MaxRetries {
+				connectionLost(err)
+				return
+			}
+
+			delay := retry.Delay(attempt)
+			attempt++
+			reconnecting(err, attempt, delay)
+			time.Sleep(delay)
+			continue
+		}
+
+		if attempt > 0 {
+			fmt.Println()
+			fmt.Println("Reconnected")
+			fmt.Print("-> ")
+			attempt = 0
+		}
+
+		if waited {
+			continue
+		}
+
+		session.Lock()
+		done := polled(g, state)
+		session.Unlock()
+		if done {
+			return
+		}
+	}
+}
+
+// polled renders a state of g received by the long poll and reports
+// whether to stop polling: once another game is started, g is over
+// or it is our turn. The session lock must be held.
+func polled(g *Game, state *tictactoe.GameState) bool {
+
+	if game != g {
+		return true
+	}
+
+	if takebackNotice(state) || rematchNotice(state) {
+		fmt.Print("-> ")
+		return false
+	}
+
+	g.update(state)
+
+	render(g)
+
+	if gameOver(state) {
+		return true
+	}
+
+	// a rematch the opponent starts
+	if g.turn != g.symbol || g.waiting {
+		return false
+	}
+
+	fmt.Println()
+	fmt.Println("Your turn!")
+	fmt.Println()
+	fmt.Print("-> ")
+	return true
+}
+
+// ReplayDelay is the pause between moves when replaying a game.
+const ReplayDelay = time.Second
+
+// replay renders the game after each of its moves.
+func replay(history *tictactoe.GameHistory) {
+
+	if len(history.Moves) == 0 {
+		fmt.Println("No moves have been played yet")
+		return
+	}
+
+	replayed := &Game{
+		id:    history.ID,
+		shape: history.Shape,
+		board: make([]tictactoe.Symbol, history.Shape.Cells()),
+	}
+
+	for i, move := range history.Moves {
+		if i > 0 {
+			time.Sleep(ReplayDelay)
+		}
+
+		replayed.board[move.Index] = move.Symbol
+
+		render(replayed)
+		fmt.Printf("Move %d: %s played %d at %s\n", i+1, move.Symbol, move.Index, move.Time.Format(time.Kitchen))
+	}
+}
+
+// connect plays, or watches, the current game over a WebSocket
+// when the server supports it and reports whether it could. The
+// session lock must be held.
+func connect(ctx context.Context, client Client) bool {
+
+	var (
+		s   Socket
+		err error
+	)
+	if game.symbol == tictactoe.Empty {
+		s, err = client.Watch(ctx, game.id)
+	} else {
+		s, err = client.Connect(ctx, game.id, game.symbol)
+	}
+	if err != nil {
+		return false
+	}
+
+	socket = s
+	go listen(ctx, client, s)
+
+	return true
+}
+
+// reconnect opens a new WebSocket to the game after the last one
+// dropped, backing off between tries. Players fall back to long
+// polling once the retries run out.
+func reconnect(ctx context.Context, client Client, g *Game, cause error) {
+
+	for attempt := 0; attempt < retry.MaxRetries; attempt++ {
+
+		delay := retry.Delay(attempt)
+		reconnecting(cause, attempt+1, delay)
+		time.Sleep(delay)
+
+		// another game was started or joined meanwhile
+		session.Lock()
+		if game != g || socket != nil {
+			session.Unlock()
+			return
+		}
+		connected := connect(ctx, client)
+		session.Unlock()
+
+		if connected {
+			fmt.Println()
+			fmt.Println("Reconnected")
+			fmt.Print("-> ")
+			return
+		}
+		cause = errors.New("could not open a WebSocket")
+	}
+
+	if g.symbol == tictactoe.Empty {
+		connectionLost(cause)
+		return
+	}
+
+	go longPoll(ctx, client, g)
+}
+
+// reconnecting tells the player the connection to the game was lost
+// and when it is tried again.
+func reconnecting(err error, attempt int, delay time.Duration) {
+	fmt.Println()
+	fmt.Printf("Connection lost: %v\n", err)
+	fmt.Printf("Reconnecting in %s (attempt %d of %d)...\n", delay.Round(100*time.Millisecond), attempt, retry.MaxRetries)
+	fmt.Print("-> ")
+}
+
+// connectionLost tells the player the retries have run out.
+func connectionLost(err error) {
+	fmt.Println()
+	fmt.Printf("Connection lost: %v\n", err)
+	fmt.Println("Gave up reconnecting, type reconnect to try again")
+	fmt.Print("-> ")
+}
+
+// disconnect closes the socket, clearing it first so its listener
+// knows the close was on purpose. The session lock must be held.
+func disconnect() {
+	if s := socket; s != nil {
+		socket = nil
+		s.Close()
+	}
+}
+
+// listen renders the states received on the socket until the game
+// is over or the connection drops, in which case it reconnects.
+func listen(ctx context.Context, client Client, s Socket) {
+
+	for {
+		msg, err := s.Receive()
+		if err != nil {
+			session.Lock()
+			// the socket was closed on purpose otherwise
+			if socket == s {
+				socket = nil
+				if game != nil {
+					go reconnect(ctx, client, game, err)
+				}
+			}
+			session.Unlock()
+			return
+		}
+
+		session.Lock()
+		done := received(s, msg)
+		session.Unlock()
+		if done {
+			return
+		}
+	}
+}
+
+// received renders a message from the socket s and reports whether
+// to stop listening, once the socket is closed or the game is gone.
+// The session lock must be held.
+func received(s Socket, msg *tictactoe.ServerMessage) bool {
+
+	// the socket was closed on purpose while the message was read
+	if socket != s {
+		return true
+	}
+
+	if msg.Type == tictactoe.ErrorMessage {
+		fmt.Println(msg.Error)
+
+		// there is no game left to reconnect to
+		var notFound *tictactoe.GameNotFoundErr
+		if errors.As(tictactoe.ErrorFromCode(msg.Code, msg.Error), &notFound) {
+			game = nil
+			disconnect()
+		}
+
+		fmt.Print("-> ")
+		return false
+	}
+
+	state := msg.State
+	if state == nil || game == nil {
+		return false
+	}
+
+	if state.Event == tictactoe.ChatEvent {
+		fmt.Println()
+		fmt.Printf("[%s] %s\n", state.Chat.From, state.Chat.Text)
+		fmt.Print("-> ")
+		return false
+	}
+
+	if takebackNotice(state) || rematchNotice(state) {
+		fmt.Print("-> ")
+		return false
+	}
+
+	// the first state is the one we already rendered
+	if state.Event == tictactoe.NoEvent && tictactoe.Hash(state.Board) == tictactoe.Hash(game.board) {
+		return false
+	}
+
+	game.update(state)
+
+	render(game)
+
+	if gameOver(state) {
+		// stay connected for a rematch unless the game is gone
+		if state.Event == tictactoe.EndedEvent {
+			game = nil
+			disconnect()
+			fmt.Print("-> ")
+			return true
+		}
+		fmt.Print("-> ")
+		return false
+	}
+
+	if game.turn == game.symbol && !game.waiting {
+		fmt.Println()
+		fmt.Println("Your turn!")
+		fmt.Println()
+	}
+	fmt.Print("-> ")
+	return false
+}
+
+// takebackNotice prints takeback requests and answers, which leave
+// the board as it is, and reports whether the state was one.
+func takebackNotice(state *tictactoe.GameState) bool {
+	switch state.Event {
+	case tictactoe.TakebackRequestEvent:
+		fmt.Println()
+		if state.Takeback == game.symbol {
+			fmt.Println("Asked to take back your last move, waiting for an answer")
+		} else {
+			fmt.Printf("%s asks to take back their last move, accept or decline?\n", state.Takeback)
+		}
+		return true
+	case tictactoe.TakebackDeclinedEvent:
+		fmt.Println()
+		fmt.Println("Takeback declined")
+		return true
+	}
+	return false
+}
+
+// rematchNotice prints rematch requests and reports whether the
+// state was one.
+func rematchNotice(state *tictactoe.GameState) bool {
+	if state.Event != tictactoe.RematchRequestEvent {
+		return false
+	}
+
+	fmt.Println()
+	if state.Rematch == game.symbol {
+		fmt.Println("Asked for a rematch, waiting for an answer")
+	} else {
+		fmt.Printf("%s wants a rematch, type rematch to play again\n", state.Rematch)
+	}
+	return true
+}
+
+// gameOver prints the outcome of a finished game and reports
+// whether the game is over.
+func gameOver(state *tictactoe.GameState) bool {
+
+	if state.Event == tictactoe.EndedEvent {
+		fmt.Println()
+		if state.Status == tictactoe.Abandoned {
+			fmt.Println("Game abandoned")
+		} else {
+			fmt.Println("Game ended")
+		}
+		return true
+	}
+
+	switch state.Status {
+	case tictactoe.WonStatus, tictactoe.Resigned:
+		fmt.Println()
+		if state.Status == tictactoe.Resigned {
+			fmt.Println("Resigned.")
+		}
+		if state.Event == tictactoe.TimeoutEvent {
+			fmt.Println("Out of time.")
+		}
+		fmt.Println("Winner!", state.Winner)
+	case tictactoe.DrawnStatus:
+		fmt.Println()
+		fmt.Println("Draw")
+	default:
+		return false
+	}
+
+	if state.Series.Winner != tictactoe.Empty {
+		fmt.Printf("%s wins the series %s\n", state.Series.Winner, score(state.Series))
+	} else if game != nil && game.symbol != tictactoe.Empty {
+		fmt.Println("Type rematch to play again")
+	}
+	return true
+}
+
+// clocks formats the time both players have left such as X 4:59 | O 5:00.
+func clocks(game *Game) string {
+	str := []string{}
+	for _, symbol := range []tictactoe.Symbol{tictactoe.X, tictactoe.O} {
+		left := game.clock.Left(symbol, game.received)
+		str = append(str, fmt.Sprintf("%s %d:%02d", symbol, int(left.Minutes()), int(left.Seconds())%60))
+	}
+	return strings.Join(str, " | ")
+}
+
+// warnings are the times left on our move that countdown warns at.
+var warnings = []time.Duration{time.Minute, 30 * time.Second, 10 * time.Second}
+
+// countdown warns when the time for our move runs low. Warnings go on
+// their own line like other news about the game, so whatever is being
+// typed at the prompt is not drawn over.
+func countdown() {
+
+	var (
+		received time.Time
+		next     int
+	)
+
+	for range time.Tick(time.Second) {
+
+		running.Lock()
+		clock, since := running.clock, running.received
+		running.Unlock()
+
+		if clock == nil {
+			continue
+		}
+
+		left := clock.Left(clock.Running, since)
+
+		// a new state, the clock above the board already shows the
+		// time left
+		if since != received {
+			received, next = since, 0
+			for next < len(warnings) && warnings[next] >= left {
+				next++
+			}
+			continue
+		}
+
+		if next == len(warnings) || left > warnings[next] {
+			continue
+		}
+		for next < len(warnings) && warnings[next] >= left {
+			next++
+		}
+
+		fmt.Println()
+		fmt.Printf("%d seconds left to move\n", int(left.Round(time.Second).Seconds()))
+		fmt.Print("-> ")
+	}
+}
+
+// score formats the wins of a series such as X 2 - O 1.
+func score(series tictactoe.Series) string {
+	str := fmt.Sprintf("X %d - O %d", series.Wins[tictactoe.X], series.Wins[tictactoe.O])
+	if series.Draws > 0 {
+		str += fmt.Sprintf(", %d drawn", series.Draws)
+	}
+	return str
+}
+
+func render(game *Game) {
+
+	shape := game.shape
+	if shape.Width == 0 || len(game.board) != shape.Cells() {
+		return
+	}
+
+	fmt.Println()
+	if game.symbol == tictactoe.Empty {
+		fmt.Printf("Watching game \"%s\"\n", game.id)
+	} else {
+		fmt.Printf("Playing game \"%s\" as %s\n", game.id, game.symbol)
+	}
+	if len(game.players) > 0 {
+		fmt.Printf("Players: X %s, O %s\n", player(game, tictactoe.X), player(game, tictactoe.O))
+	}
+	if game.spectators > 0 {
+		fmt.Printf("Spectators: %d\n", game.spectators)
+	}
+	if game.series.BestOf > 0 {
+		fmt.Printf("Series: %s (best of %d, game %d)\n", score(game.series), game.series.BestOf, game.series.Round)
+	} else if game.series.Round > 1 {
+		fmt.Printf("Series: %s (game %d)\n", score(game.series), game.series.Round)
+	}
+	if game.clock != nil {
+		fmt.Printf("Clock: %s\n", clocks(game))
+	}
+	if game.turn != tictactoe.Empty {
+		fmt.Printf("Turn: %s\n", game.turn)
+	}
+
+	columns := []string{}
+	divider := []string{}
+	for col := 0; col < shape.Width; col++ {
+		columns = append(columns, " "+string(rune('A'+col))+" ")
+		divider = append(divider, "---")
+	}
+
+	fmt.Println("   " + strings.Join(columns, " "))
+	for row := 0; row < shape.Height; row++ {
+		if row > 0 {
+			fmt.Println("   " + strings.Join(divider, "+"))
+		}
+
+		cells := []string{}
+		for col := 0; col < shape.Width; col++ {
+			cells = append(cells, " "+empty(game.board[row*shape.Width+col])+" ")
+		}
+		fmt.Printf("%2d %s\n", row+1, strings.Join(cells, "|"))
+	}
+}
+
+// hint prints the board with the outcome of playing each empty cell
+// for the player to move: W and L with the moves left until the game
+// is won or lost, D for a draw.
+func hint(analysis *tictactoe.Analysis) {
+
+	shape := analysis.Shape
+
+	cells := make([]string, len(analysis.Board))
+	for i, s := range analysis.Board {
+		cells[i] = " " + empty(s) + " "
+	}
+	for _, cell := range analysis.Cells {
+		switch cell.Outcome {
+		case tictactoe.WinOutcome:
+			cells[cell.Index] = fmt.Sprintf("W%-2d", cell.Plies)
+		case tictactoe.LossOutcome:
+			cells[cell.Index] = fmt.Sprintf("L%-2d", cell.Plies)
+		default:
+			cells[cell.Index] = " D "
+		}
+	}
+
+	columns := []string{}
+	divider := []string{}
+	for col := 0; col < shape.Width; col++ {
+		columns = append(columns, " "+string(rune('A'+col))+" ")
+		divider = append(divider, "---")
+	}
+
+	fmt.Println()
+	fmt.Println("   " + strings.Join(columns, " "))
+	for row := 0; row < shape.Height; row++ {
+		if row > 0 {
+			fmt.Println("   " + strings.Join(divider, "+"))
+		}
+		fmt.Printf("%2d %s\n", row+1, strings.Join(cells[row*shape.Width:(row+1)*shape.Width], "|"))
+	}
+	fmt.Println()
+
+	best := []string{}
+	for _, i := range analysis.Best {
+		best = append(best, cellName(i, shape))
+	}
+
+	switch analysis.Outcome {
+	case tictactoe.WinOutcome:
+		fmt.Printf("%s wins in %d moves with best play\n", analysis.Turn, analysis.Plies)
+	case tictactoe.LossOutcome:
+		fmt.Printf("%s loses in %d moves with best play\n", analysis.Turn, analysis.Plies)
+	default:
+		fmt.Println("Draw with best play")
+	}
+	fmt.Printf("Best: %s\n", strings.Join(best, ", "))
+	fmt.Println("W and L are the moves left until the game is won or lost, D is a draw")
+}
+
+const playUsage = "Usage: play [--size=<width>x<height>] [--win=<length>] [--best-of=<games>] [--clock=<minutes>+<increment>] [--per-move=<seconds>]"
+
+// ListPageSize is how many games list shows at once.
+const ListPageSize = 20
+
+const listUsage = "Usage: list [--status=<open|playing|finished>] [--page=<n>]"
+
+// parseListFlags reads the optional flags of the list command.
+func parseListFlags(flags []string) (tictactoe.GameFilter, error) {
+
+	filter := tictactoe.GameFilter{Limit: ListPageSize}
+
+	for _, flag := range flags {
+		switch {
+		case strings.HasPrefix(flag, "--status="):
+			filter.Status = tictactoe.Status(strings.TrimPrefix(flag, "--status="))
+
+		case strings.HasPrefix(flag, "--page="):
+			page, err := strconv.Atoi(strings.TrimPrefix(flag, "--page="))
+			if err != nil || page < 1 {
+				return filter, fmt.Errorf("Cannot parse page %s", flag)
+			}
+			filter.Offset = (page - 1) * ListPageSize
+
+		default:
+			return filter, fmt.Errorf("Unknown flag %s", flag)
+		}
+	}
+
+	return filter, nil
+}
+
+// list prints a page of games as a table.
+func list(games *tictactoe.GameList, filter tictactoe.GameFilter) {
+
+	if games.Total == 0 {
+		fmt.Println("No games found")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tBOARD\tSTATUS\tTURN\tOPEN\tWATCHING\tCREATED")
+
+	for _, g := range games.Games {
+		seats := []string{}
+		for _, seat := range g.Seats {
+			seats = append(seats, string(seat))
+		}
+
+		fmt.Fprintf(w, "%s\t%dx%d (%d)\t%s\t%s\t%s\t%d\t%s\n",
+			g.ID, g.Width, g.Height, g.WinLength, g.Status, empty(g.Turn),
+			strings.Join(seats, ","), g.Spectators, g.Created.Format("Jan 2 15:04"))
+	}
+	w.Flush()
+
+	pages := (games.Total + ListPageSize - 1) / ListPageSize
+	fmt.Printf("Page %d of %d, %d games\n", filter.Offset/ListPageSize+1, pages, games.Total)
+}
+
+const createUsage = "Usage: create <game name> <piece> [--size=<width>x<height>] [--win=<length>] [--vs-ai=<easy|medium|hard>] [--best-of=<games>] [--clock=<minutes>+<increment>] [--per-move=<seconds>]"
+
+// parseCreateFlags reads the optional flags of the create command.
+// When only the size is given the win length is the shorter side,
+// capped at five.
+func parseCreateFlags(flags []string) (tictactoe.GameOptions, error) {
+
+	opts := tictactoe.GameOptions{}
+
+	for _, flag := range flags {
+		switch {
+		case strings.HasPrefix(flag, "--vs-ai="):
+			opts.AI = tictactoe.Difficulty(strings.TrimPrefix(flag, "--vs-ai="))
+
+		case strings.HasPrefix(flag, "--size="):
+			size := strings.Split(strings.TrimPrefix(flag, "--size="), "x")
+			if len(size) != 2 {
+				return opts, fmt.Errorf("Cannot parse size %s", flag)
+			}
+
+			width, err := strconv.Atoi(size[0])
+			if err != nil {
+				return opts, fmt.Errorf("Cannot parse width %s", size[0])
+			}
+			height, err := strconv.Atoi(size[1])
+			if err != nil {
+				return opts, fmt.Errorf("Cannot parse height %s", size[1])
+			}
+			opts.Width, opts.Height = width, height
+
+		case strings.HasPrefix(flag, "--win="):
+			win, err := strconv.Atoi(strings.TrimPrefix(flag, "--win="))
+			if err != nil {
+				return opts, fmt.Errorf("Cannot parse win length %s", flag)
+			}
+			opts.WinLength = win
+
+		case strings.HasPrefix(flag, "--best-of="):
+			games, err := strconv.Atoi(strings.TrimPrefix(flag, "--best-of="))
+			if err != nil {
+				return opts, fmt.Errorf("Cannot parse series length %s", flag)
+			}
+			opts.BestOf = games
+
+		case strings.HasPrefix(flag, "--clock="):
+			clock := strings.Split(strings.TrimPrefix(flag, "--clock="), "+")
+
+			minutes, err := strconv.Atoi(clock[0])
+			if err != nil {
+				return opts, fmt.Errorf("Cannot parse clock %s", flag)
+			}
+			opts.TimeControl.Base = time.Duration(minutes) * time.Minute
+
+			if len(clock) == 2 {
+				increment, err := strconv.Atoi(clock[1])
+				if err != nil {
+					return opts, fmt.Errorf("Cannot parse increment %s", flag)
+				}
+				opts.TimeControl.Increment = time.Duration(increment) * time.Second
+			}
+
+		case strings.HasPrefix(flag, "--per-move="):
+			seconds, err := strconv.Atoi(strings.TrimPrefix(flag, "--per-move="))
+			if err != nil {
+				return opts, fmt.Errorf("Cannot parse time per move %s", flag)
+			}
+			opts.TimeControl.PerMove = time.Duration(seconds) * time.Second
+
+		default:
+			return opts, fmt.Errorf("Unknown flag %s", flag)
+		}
+	}
+
+	if opts.Shape == (tictactoe.Shape{}) {
+		return opts, nil
+	}
+
+	if opts.Width == 0 {
+		opts.Width, opts.Height = tictactoe.Classic.Width, tictactoe.Classic.Height
+	}
+
+	if opts.WinLength == 0 {
+		opts.WinLength = opts.Width
+		if opts.Height < opts.WinLength {
+			opts.WinLength = opts.Height
+		}
+		if opts.WinLength > 5 {
+			opts.WinLength = 5
+		}
+	}
+
+	return opts, nil
+}
+
+// parseCell reads a cell either as an index or as a coordinate
+// such as b3, column letter first.
+func parseCell(arg string, shape tictactoe.Shape) (int, error) {
+
+	if index, err := strconv.Atoi(arg); err == nil {
+		return index, nil
+	}
+
+	arg = strings.ToUpper(arg)
+	if len(arg) < 2 || arg[0] < 'A' || arg[0] > 'Z' {
+		return 0, fmt.Errorf("Cannot parse cell %s", arg)
+	}
+
+	col := int(arg[0] - 'A')
+	row, err := strconv.Atoi(arg[1:])
+	if err != nil || col >= shape.Width || row < 1 || row > shape.Height {
+		return 0, fmt.Errorf("Cannot parse cell %s", arg)
+	}
+
+	return (row-1)*shape.Width + col, nil
+}
+
+// cellName is the coordinate of a cell as parseCell reads it, such
+// as B3.
+func cellName(index int, shape tictactoe.Shape) string {
+	return fmt.Sprintf("%c%d", rune('A'+index%shape.Width), index/shape.Width+1)
+}
+
+// player is the name on a seat, or guest when it is not claimed.
+func player(game *Game, symbol tictactoe.Symbol) string {
+	if name, ok := game.players[symbol]; ok {
+		return name
 	}
+	return "guest"
 }
 
 func empty(s tictactoe.Symbol) string {
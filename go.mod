@@ -4,6 +4,8 @@ go 1.15
 
 require (
 	github.com/go-chi/chi v1.5.2
+	github.com/gorilla/websocket v1.4.2
 	github.com/satori/go.uuid v1.2.0
 	github.com/stretchr/testify v1.7.0
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/svolpe43/ttt/client"
+	"github.com/svolpe43/ttt/server/tictactoe"
+)
+
+// newTestServer serves the API on a local port and returns a client
+// for it. Long polls give up quickly so tests do not wait on them.
+func newTestServer(t *testing.T) (*httptest.Server, client.Client) {
+
+	config := DefaultConfig()
+	config.LongPollWait = 200 * time.Millisecond
+
+	srv := httptest.NewServer(NewServer(tictactoe.NewTicTacToe(), config).(*server).routes())
+	t.Cleanup(srv.Close)
+
+	return srv, client.New(client.WithHost(srv.URL), client.WithTimeout(5*time.Second))
+}
+
+func TestClientGame(t *testing.T) {
+
+	srv, x := newTestServer(t)
+	o := client.New(client.WithHost(srv.URL))
+	ctx := context.Background()
+
+	created, err := x.CreateGame(ctx, "game", tictactoe.X, tictactoe.GameOptions{})
+	require.NoError(t, err)
+	require.Equal(t, tictactoe.X, created.Symbol)
+	require.Equal(t, tictactoe.Waiting, created.State.Status)
+	require.Equal(t, created.Token, x.Token("game"))
+
+	open, err := o.ListGames(ctx, tictactoe.GameFilter{Status: tictactoe.Open})
+	require.NoError(t, err)
+	require.Equal(t, 1, open.Total)
+	require.Equal(t, tictactoe.GameID("game"), open.Games[0].ID)
+
+	joined, err := o.JoinGame(ctx, "game")
+	require.NoError(t, err)
+	require.Equal(t, tictactoe.O, joined.Symbol)
+	require.Equal(t, tictactoe.Playing, joined.State.Status)
+
+	state, err := x.Move(ctx, "game", tictactoe.X, 4)
+	require.NoError(t, err)
+	require.Equal(t, tictactoe.X, state.Board[4])
+
+	state, err = o.GetGame(ctx, "game")
+	require.NoError(t, err)
+	require.Equal(t, tictactoe.O, state.Turn)
+
+	// the long poll answers with O's move, whether it was already
+	// waiting or finds the board changed
+	hash := tictactoe.Hash(state.Board)
+	polled := make(chan *tictactoe.GameState, 1)
+	go func() {
+		state, _ := x.Poll(ctx, "game", hash)
+		polled <- state
+	}()
+
+	_, err = o.Move(ctx, "game", tictactoe.O, 0)
+	require.NoError(t, err)
+
+	state = <-polled
+	require.NotNil(t, state, "the long poll did not answer the move")
+	require.Equal(t, tictactoe.O, state.Board[0])
+
+	history, err := x.History(ctx, "game")
+	require.NoError(t, err)
+	require.Len(t, history.Moves, 2)
+
+	require.NoError(t, x.EndGame(ctx, "game"))
+	require.Empty(t, x.Token("game"))
+
+	_, err = x.GetGame(ctx, "game")
+	require.True(t, errors.As(err, new(*tictactoe.GameNotFoundErr)))
+}
+
+func TestClientErrors(t *testing.T) {
+
+	srv, x := newTestServer(t)
+	o := client.New(client.WithHost(srv.URL))
+	ctx := context.Background()
+
+	_, err := x.CreateGame(ctx, "game", tictactoe.X, tictactoe.GameOptions{})
+	require.NoError(t, err)
+
+	_, err = x.CreateGame(ctx, "game", tictactoe.X, tictactoe.GameOptions{})
+	require.True(t, errors.As(err, new(*tictactoe.GameExistsErr)), "got %v", err)
+
+	_, err = x.Move(ctx, "game", tictactoe.X, 0)
+	require.True(t, errors.As(err, new(*tictactoe.GameNotStartedErr)), "got %v", err)
+
+	// names of other routes are not games
+	for id := range reservedIDs {
+		_, err = x.CreateGame(ctx, id, tictactoe.X, tictactoe.GameOptions{})
+		require.True(t, errors.As(err, new(*tictactoe.ReservedGameIDErr)), "got %v for %s", err, id)
+	}
+
+	_, err = x.JoinGame(ctx, "missing")
+	require.True(t, errors.As(err, new(*tictactoe.GameNotFoundErr)), "got %v", err)
+
+	_, err = x.ListGames(ctx, tictactoe.GameFilter{Status: "sideways"})
+	require.True(t, errors.As(err, new(*tictactoe.InvalidFilterErr)), "got %v", err)
+
+	_, err = o.JoinGame(ctx, "game")
+	require.NoError(t, err)
+
+	_, err = o.Move(ctx, "game", tictactoe.O, 0)
+	require.True(t, errors.As(err, new(*tictactoe.NotYourTurnErr)), "got %v", err)
+
+	_, err = x.Move(ctx, "game", tictactoe.X, 9)
+	require.True(t, errors.As(err, new(*tictactoe.OffBoardErr)), "got %v", err)
+
+	// O's token does not hold X's seat
+	_, err = o.Move(ctx, "game", tictactoe.X, 0)
+	require.True(t, errors.As(err, new(*tictactoe.InvalidTokenErr)), "got %v", err)
+
+	// the long poll times out when nothing happens
+	state, err := x.GetGame(ctx, "game")
+	require.NoError(t, err)
+	_, err = x.Poll(ctx, "game", tictactoe.Hash(state.Board))
+	require.True(t, errors.As(err, new(*tictactoe.TimeoutErr)), "got %v", err)
+}
+
+func TestClientSubscribe(t *testing.T) {
+
+	srv, x := newTestServer(t)
+	o := client.New(client.WithHost(srv.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := x.CreateGame(ctx, "game", tictactoe.X, tictactoe.GameOptions{})
+	require.NoError(t, err)
+	_, err = o.JoinGame(ctx, "game")
+	require.NoError(t, err)
+
+	stream, err := x.Subscribe(ctx, "game")
+	require.NoError(t, err)
+	defer stream.Close()
+
+	// the current state comes first
+	state := <-stream.States()
+	require.Equal(t, tictactoe.Playing, state.Status)
+
+	// X wins along the top row
+	players := []client.Client{x, o}
+	symbols := []tictactoe.Symbol{tictactoe.X, tictactoe.O}
+	for i, index := range []int{0, 3, 1, 4, 2} {
+		_, err := players[i%2].Move(ctx, "game", symbols[i%2], index)
+		require.NoError(t, err)
+
+		state = <-stream.States()
+		require.Equal(t, symbols[i%2], state.Board[index])
+	}
+	require.Equal(t, tictactoe.WonStatus, state.Status)
+
+	require.NoError(t, x.EndGame(ctx, "game"))
+
+	state = <-stream.States()
+	require.Equal(t, tictactoe.EndedEvent, state.Event)
+
+	_, ok := <-stream.States()
+	require.False(t, ok)
+	require.NoError(t, stream.Err())
+}
+
+func TestClientFollow(t *testing.T) {
+
+	srv, x := newTestServer(t)
+	o := client.New(client.WithHost(srv.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := x.CreateGame(ctx, "game", tictactoe.X, tictactoe.GameOptions{})
+	require.NoError(t, err)
+	_, err = o.JoinGame(ctx, "game")
+	require.NoError(t, err)
+
+	// follow until X has moved, moving once the current state came
+	moved := errors.New("moved")
+	started := make(chan struct{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- x.Follow(ctx, "game", func(state tictactoe.GameState) error {
+			notify(started)
+			if state.Board[0] == tictactoe.X {
+				return moved
+			}
+			return nil
+		})
+	}()
+
+	<-started
+	_, err = x.Move(ctx, "game", tictactoe.X, 0)
+	require.NoError(t, err)
+
+	require.Equal(t, moved, <-done)
+
+	// follow until the game ends
+	started = make(chan struct{}, 1)
+	go func() {
+		done <- x.Follow(ctx, "game", func(tictactoe.GameState) error {
+			notify(started)
+			return nil
+		})
+	}()
+
+	<-started
+	require.NoError(t, x.EndGame(ctx, "game"))
+	require.NoError(t, <-done)
+
+	// the context stops following
+	_, err = x.CreateGame(ctx, "other", tictactoe.X, tictactoe.GameOptions{})
+	require.NoError(t, err)
+
+	short, stop := context.WithCancel(ctx)
+	stop()
+	err = x.Follow(short, "other", func(tictactoe.GameState) error {
+		return nil
+	})
+	require.True(t, errors.Is(err, context.Canceled), "got %v", err)
+}
+
+// notify notes on c that something happened, once is enough.
+func notify(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+func TestClientSocket(t *testing.T) {
+
+	srv, x := newTestServer(t)
+	o := client.New(client.WithHost(srv.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := x.CreateGame(ctx, "game", tictactoe.X, tictactoe.GameOptions{})
+	require.NoError(t, err)
+	_, err = o.JoinGame(ctx, "game")
+	require.NoError(t, err)
+
+	sock, err := x.Connect(ctx, "game", tictactoe.X)
+	require.NoError(t, err)
+	defer sock.Close()
+
+	msg, err := sock.Receive()
+	require.NoError(t, err)
+	require.Equal(t, tictactoe.StateMessage, msg.Type)
+
+	require.NoError(t, sock.Send(tictactoe.ClientMessage{Type: tictactoe.MoveMessage, Index: 4}))
+
+	msg, err = sock.Receive()
+	require.NoError(t, err)
+	require.Equal(t, tictactoe.X, msg.State.Board[4])
+
+	// refusals come back with their code
+	require.NoError(t, sock.Send(tictactoe.ClientMessage{Type: tictactoe.MoveMessage, Index: 0}))
+
+	msg, err = sock.Receive()
+	require.NoError(t, err)
+	require.Equal(t, tictactoe.ErrorMessage, msg.Type)
+	require.True(t, errors.As(tictactoe.ErrorFromCode(msg.Code, msg.Error), new(*tictactoe.NotYourTurnErr)))
+}
+
+func TestClientAnalyze(t *testing.T) {
+
+	_, c := newTestServer(t)
+	ctx := context.Background()
+
+	// X to complete the top row
+	board := []tictactoe.Symbol{
+		tictactoe.X, tictactoe.X, tictactoe.Empty,
+		tictactoe.O, tictactoe.O, tictactoe.Empty,
+		tictactoe.Empty, tictactoe.Empty, tictactoe.Empty,
+	}
+
+	analysis, err := c.Analyze(ctx, tictactoe.Shape{}, board, tictactoe.Empty)
+	require.NoError(t, err)
+	require.Equal(t, tictactoe.X, analysis.Turn)
+	require.Equal(t, tictactoe.WinOutcome, analysis.Outcome)
+	require.Equal(t, []int{2}, analysis.Best)
+	require.Len(t, analysis.Cells, 5)
+
+	// O moves first on a larger board
+	shape := tictactoe.Shape{Width: 4, Height: 3, WinLength: 3}
+	analysis, err = c.Analyze(ctx, shape, make([]tictactoe.Symbol, 12), tictactoe.O)
+	require.NoError(t, err)
+	require.Equal(t, tictactoe.O, analysis.Turn)
+	require.Equal(t, shape, analysis.Shape)
+
+	_, err = c.Analyze(ctx, tictactoe.Shape{}, board[:8], tictactoe.Empty)
+	require.True(t, errors.As(err, new(*tictactoe.InvalidPositionErr)), "got %v", err)
+
+	_, err = c.Analyze(ctx, tictactoe.Shape{Width: 5, Height: 5, WinLength: 4}, make([]tictactoe.Symbol, 25), tictactoe.Empty)
+	require.True(t, errors.As(err, new(*tictactoe.TooLargeToSolveErr)), "got %v", err)
+}
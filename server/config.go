@@ -0,0 +1,232 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The defaults of the settings that have one.
+const (
+	DefaultAddr            = ":8080"
+	DefaultReadTimeout     = 15 * time.Second
+	DefaultIdleTimeout     = 2 * time.Minute
+	DefaultLongPollWait    = 30 * time.Second
+	DefaultShutdownTimeout = 10 * time.Second
+)
+
+// EnvPrefix starts the name of every environment variable the
+// server reads its settings from, such as TTT_ADDR.
+const EnvPrefix = "TTT_"
+
+// Config is how the server is run. Each setting is read from, in
+// order of precedence, a command line flag, an environment variable
+// and the config file, falling back to its default.
+type Config struct {
+	// Addr is the address to listen on
+	Addr string `yaml:"addr"`
+	// TLSCert and TLSKey are the files of the certificate to serve
+	// HTTPS with, plain HTTP is served without them
+	TLSCert string `yaml:"tls_cert"`
+	TLSKey  string `yaml:"tls_key"`
+	// ReadTimeout and IdleTimeout limit how long a connection may
+	// take to send a request and stay open between requests.
+	// WriteTimeout limits how long a response may take, which
+	// includes long polls and event streams, zero for no limit.
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+	// LongPollWait is how long a long poll waits for a new state
+	LongPollWait time.Duration `yaml:"long_poll_wait"`
+	// ShutdownTimeout is how long open requests are given to finish
+	// once the server is told to stop
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// MaxGames caps the games hosted at once, zero for no cap
+	MaxGames int `yaml:"max_games"`
+	// CORSOrigins are the origins browsers may call the API from,
+	// * for any
+	CORSOrigins []string `yaml:"cors_origins"`
+	// DataDir keeps the games so they survive restarts, games are
+	// kept in memory when empty
+	DataDir string `yaml:"data"`
+}
+
+// DefaultConfig returns the settings used when nothing is set.
+func DefaultConfig() *Config {
+	return &Config{
+		Addr:            DefaultAddr,
+		ReadTimeout:     DefaultReadTimeout,
+		IdleTimeout:     DefaultIdleTimeout,
+		LongPollWait:    DefaultLongPollWait,
+		ShutdownTimeout: DefaultShutdownTimeout,
+	}
+}
+
+// setting is a config setting that can be given as a flag or an
+// environment variable, both of which are strings.
+type setting struct {
+	name  string
+	usage string
+	set   func(c *Config, value string) error
+}
+
+var settings = []setting{
+	{"addr", "address to listen on", func(c *Config, v string) error {
+		c.Addr = v
+		return nil
+	}},
+	{"tls-cert", "certificate file to serve HTTPS with, needs tls-key", func(c *Config, v string) error {
+		c.TLSCert = v
+		return nil
+	}},
+	{"tls-key", "key file of the certificate, needs tls-cert", func(c *Config, v string) error {
+		c.TLSKey = v
+		return nil
+	}},
+	{"read-timeout", "longest a request may take to read, such as 15s", func(c *Config, v string) error {
+		return setDuration(&c.ReadTimeout, v)
+	}},
+	{"write-timeout", "longest a response may take to write, 0 for no limit", func(c *Config, v string) error {
+		return setDuration(&c.WriteTimeout, v)
+	}},
+	{"idle-timeout", "longest a connection may stay open between requests", func(c *Config, v string) error {
+		return setDuration(&c.IdleTimeout, v)
+	}},
+	{"long-poll-wait", "how long a long poll waits for a new state", func(c *Config, v string) error {
+		return setDuration(&c.LongPollWait, v)
+	}},
+	{"shutdown-timeout", "how long open requests get to finish when stopping", func(c *Config, v string) error {
+		return setDuration(&c.ShutdownTimeout, v)
+	}},
+	{"max-games", "most games hosted at once, 0 for no limit", func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return errors.New("not a number")
+		}
+		c.MaxGames = n
+		return nil
+	}},
+	{"cors-origins", "comma separated origins browsers may call the API from, * for any", func(c *Config, v string) error {
+		c.CORSOrigins = nil
+		for _, origin := range strings.Split(v, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				c.CORSOrigins = append(c.CORSOrigins, origin)
+			}
+		}
+		return nil
+	}},
+	{"data", "directory to keep games in so they survive restarts, games are kept in memory when empty", func(c *Config, v string) error {
+		c.DataDir = v
+		return nil
+	}},
+}
+
+func setDuration(d *time.Duration, value string) error {
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return errors.New("not a duration such as 30s")
+	}
+	*d = parsed
+	return nil
+}
+
+// envName is the environment variable of a setting, such as
+// TTT_LONG_POLL_WAIT for long-poll-wait.
+func envName(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.Replace(name, "-", "_", -1))
+}
+
+// LoadConfig reads the config from the command line arguments, the
+// environment read with getenv and the config file named by the
+// config flag or the TTT_CONFIG variable. The file is YAML, which
+// includes JSON.
+func LoadConfig(args []string, getenv func(string) string) (*Config, error) {
+
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	file := fs.String("config", getenv(envName("config")), "YAML or JSON file to read the settings from")
+
+	flags := map[string]*string{}
+	for _, s := range settings {
+		flags[s.name] = fs.String(s.name, "", fmt.Sprintf("%s (env %s)", s.usage, envName(s.name)))
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	config := DefaultConfig()
+
+	if *file != "" {
+		data, err := ioutil.ReadFile(*file)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", *file, err)
+		}
+	}
+
+	for _, s := range settings {
+		if value := getenv(envName(s.name)); value != "" {
+			if err := s.set(config, value); err != nil {
+				return nil, fmt.Errorf("%s: %w", envName(s.name), err)
+			}
+		}
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	for _, s := range settings {
+		if set[s.name] {
+			if err := s.set(config, *flags[s.name]); err != nil {
+				return nil, fmt.Errorf("-%s: %w", s.name, err)
+			}
+		}
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// validate catches settings that cannot work together.
+func (c *Config) validate() error {
+
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		return errors.New("tls-cert and tls-key must be given together")
+	}
+
+	if c.ReadTimeout < 0 || c.WriteTimeout < 0 || c.IdleTimeout < 0 || c.ShutdownTimeout < 0 {
+		return errors.New("timeouts cannot be negative")
+	}
+
+	if c.LongPollWait <= 0 {
+		return errors.New("long-poll-wait must be positive")
+	}
+
+	// long polls and matchmaking have to be able to answer before
+	// they are cut off
+	if c.WriteTimeout > 0 && (c.WriteTimeout <= c.LongPollWait || c.WriteTimeout <= MatchmakeMaxWait) {
+		return fmt.Errorf("write-timeout must be longer than long-poll-wait and %s for matchmaking", MatchmakeMaxWait)
+	}
+
+	if c.MaxGames < 0 {
+		return errors.New("max-games cannot be negative")
+	}
+
+	return nil
+}
+
+// TLS reports whether the server is served over HTTPS.
+func (c *Config) TLS() bool {
+	return c.TLSCert != ""
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigPrecedence(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, ioutil.WriteFile(file, []byte("long_poll_wait: 20s\nmax_games: 5\n"), 0600))
+
+	for _, tc := range []struct {
+		name string
+		file bool
+		env  string
+		flag string
+		want time.Duration
+	}{
+		{"default", false, "", "", DefaultLongPollWait},
+		{"file over default", true, "", "", 20 * time.Second},
+		{"env over file", true, "15s", "", 15 * time.Second},
+		{"flag over env", true, "15s", "5s", 5 * time.Second},
+		{"flag over default", false, "", "5s", 5 * time.Second},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+
+			env := map[string]string{"TTT_LONG_POLL_WAIT": tc.env}
+			if tc.file {
+				env["TTT_CONFIG"] = file
+			}
+			args := []string{}
+			if tc.flag != "" {
+				args = append(args, "-long-poll-wait", tc.flag)
+			}
+
+			config, err := LoadConfig(args, func(name string) string {
+				return env[name]
+			})
+			require.NoError(t, err)
+			require.Equal(t, tc.want, config.LongPollWait)
+
+			// settings given nowhere else come from the file
+			if tc.file {
+				require.Equal(t, 5, config.MaxGames)
+			}
+			require.Equal(t, DefaultAddr, config.Addr)
+		})
+	}
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, ioutil.WriteFile(file, []byte("max_games: [1, 2]\n"), 0600))
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		env  map[string]string
+	}{
+		{"unknown flag", []string{"-colour", "blue"}, nil},
+		{"bad duration flag", []string{"-read-timeout", "soon"}, nil},
+		{"bad number env", nil, map[string]string{"TTT_MAX_GAMES": "many"}},
+		{"missing file", []string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}, nil},
+		{"bad file", []string{"-config", file}, nil},
+		{"cert without key", []string{"-tls-cert", "cert.pem"}, nil},
+		{"negative timeout", []string{"-idle-timeout", "-1s"}, nil},
+		{"no long poll wait", []string{"-long-poll-wait", "0s"}, nil},
+		{"write timeout too short", []string{"-write-timeout", "10s", "-long-poll-wait", "30s"}, nil},
+		{"negative max games", nil, map[string]string{"TTT_MAX_GAMES": "-1"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadConfig(tc.args, func(name string) string {
+				return tc.env[name]
+			})
+			require.Error(t, err)
+		})
+	}
+}
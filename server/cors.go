@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/svolpe43/ttt/server/tictactoe"
+)
+
+// corsHeaders are the request headers browsers may send across
+// origins.
+var corsHeaders = strings.Join([]string{
+	"Content-Type",
+	"Last-Event-ID",
+	tictactoe.TokenHeader,
+	tictactoe.NameHeader,
+	tictactoe.KeyHeader,
+}, ", ")
+
+// allowOrigin reports whether browsers on the origin may call the API.
+func (s *server) allowOrigin(origin string) bool {
+	for _, allowed := range s.config.CORSOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// cors lets browsers on the configured origins call the API and
+// answers their preflight requests.
+func (s *server) cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		origin := r.Header.Get("Origin")
+		if origin == "" || !s.allowOrigin(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Add("Vary", "Origin")
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE")
+			w.Header().Set("Access-Control-Allow-Headers", corsHeaders)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkOrigin lets WebSockets connect from the server's own host and
+// the configured origins.
+func (s *server) checkOrigin(r *http.Request) bool {
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	// the same origin check of the default upgrader
+	host := strings.TrimPrefix(strings.TrimPrefix(origin, "https://"), "http://")
+	if strings.EqualFold(host, r.Host) {
+		return true
+	}
+
+	return s.allowOrigin(origin)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/svolpe43/ttt/server/tictactoe"
+)
+
+func TestCORS(t *testing.T) {
+
+	config := DefaultConfig()
+	config.CORSOrigins = []string{"https://play.example.com"}
+	srv := httptest.NewServer(NewServer(tictactoe.NewTicTacToe(), config).(*server).routes())
+	defer srv.Close()
+
+	request := func(method, origin string) *http.Response {
+		req, err := http.NewRequest(method, srv.URL+"/", nil)
+		require.NoError(t, err)
+		req.Header.Set("Origin", origin)
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	// preflight from an allowed origin
+	resp := request(http.MethodOptions, "https://play.example.com")
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Equal(t, "https://play.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "GET, POST, DELETE", resp.Header.Get("Access-Control-Allow-Methods"))
+	require.Contains(t, resp.Header.Get("Access-Control-Allow-Headers"), tictactoe.TokenHeader)
+	require.Equal(t, "Origin", resp.Header.Get("Vary"))
+
+	// requests from an allowed origin
+	resp = request(http.MethodGet, "https://play.example.com")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "https://play.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+
+	// other origins get no CORS headers
+	resp = request(http.MethodGet, "https://evil.example.com")
+	require.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+	resp = request(http.MethodOptions, "https://evil.example.com")
+	require.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+	require.Empty(t, resp.Header.Get("Access-Control-Allow-Methods"))
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/svolpe43/ttt/server/tictactoe"
+)
+
+// errorStatus is the HTTP status of each error code. Codes missing
+// here are bad requests.
+var errorStatus = map[tictactoe.ErrorCode]int{
+	tictactoe.GameNotFoundCode:    http.StatusNotFound,
+	tictactoe.AccountNotFoundCode: http.StatusNotFound,
+
+	tictactoe.NotYourTurnCode:  http.StatusForbidden,
+	tictactoe.InvalidTokenCode: http.StatusForbidden,
+
+	tictactoe.GameExistsCode:        http.StatusConflict,
+	tictactoe.AccountExistsCode:     http.StatusConflict,
+	tictactoe.TooManyPlayersCode:    http.StatusConflict,
+	tictactoe.IllegalMoveCode:       http.StatusConflict,
+	tictactoe.GameOverCode:          http.StatusConflict,
+	tictactoe.GameNotStartedCode:    http.StatusConflict,
+	tictactoe.GameNotOverCode:       http.StatusConflict,
+	tictactoe.SeriesOverCode:        http.StatusConflict,
+	tictactoe.NoTakebackCode:        http.StatusConflict,
+	tictactoe.NothingToTakeBackCode: http.StatusConflict,
+	tictactoe.SeatInPlayCode:        http.StatusConflict,
+	tictactoe.SamePlayerCode:        http.StatusConflict,
+
+	tictactoe.TooManyGamesCode: http.StatusServiceUnavailable,
+	tictactoe.ShuttingDownCode: http.StatusServiceUnavailable,
+
+	tictactoe.TimeoutCode:  http.StatusRequestTimeout,
+	tictactoe.InternalCode: http.StatusInternalServerError,
+}
+
+// writeError responds with the JSON error envelope and the status
+// for the error's code. The details of internal errors are logged
+// rather than sent.
+func writeError(w http.ResponseWriter, err error) {
+
+	code := tictactoe.CodeOf(err)
+
+	status, ok := errorStatus[code]
+	if !ok {
+		status = http.StatusBadRequest
+	}
+
+	message := err.Error()
+	if code == tictactoe.InternalCode {
+		log.Printf("ERROR: %v", err)
+		message = "Internal server error"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(tictactoe.ErrorResponse{
+		Code:    code,
+		Message: message,
+	})
+}
+
+// badRequest responds that the request could not be understood.
+func badRequest(w http.ResponseWriter, reason string) {
+	writeError(w, &tictactoe.BadRequestErr{Reason: reason})
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/svolpe43/ttt/server/tictactoe"
+)
+
+// EventsKeepAlive is how often an idle event stream sends a comment
+// so proxies do not close the connection.
+const EventsKeepAlive = 15 * time.Second
+
+// Events streams every state published for a game as Server-Sent
+// Events. The event ID is the state's sequence number so a client
+// reconnecting with Last-Event-ID receives the states it missed, or
+// the current state once they are older than the replay buffer. New
+// clients first receive the current state of the game.
+func (s *server) Events(w http.ResponseWriter, r *http.Request) {
+
+	gameID := tictactoe.GameID(chi.URLParam(r, "id"))
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, errors.New("streaming is not supported"))
+		return
+	}
+
+	ctx := r.Context()
+
+	var (
+		sub      *tictactoe.Subscription
+		snapshot *tictactoe.GameState
+		err      error
+	)
+
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+
+		seq, err := strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			badRequest(w, "Could not parse Last-Event-ID")
+			return
+		}
+
+		sub, err = s.tictactoe.SubscribeAfter(ctx, gameID, seq, tictactoe.DefaultBuffer, tictactoe.Disconnect)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+	} else {
+
+		snapshot, err = s.tictactoe.GetGame(gameID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		// anything published since the snapshot is replayed
+		sub, err = s.tictactoe.SubscribeAfter(ctx, gameID, snapshot.Seq, tictactoe.DefaultBuffer, tictactoe.Disconnect)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	defer sub.Unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if snapshot != nil {
+		if err := writeEvent(w, snapshot); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(EventsKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if s.closing.Err() != nil {
+				writeShutdown(w)
+				flusher.Flush()
+			}
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case state, ok := <-sub.Events():
+			// the game ended, the client fell behind or the server
+			// is shutting down
+			if !ok {
+				if s.closing.Err() != nil {
+					writeShutdown(w)
+					flusher.Flush()
+				}
+				return
+			}
+
+			if err := writeEvent(w, &state); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeShutdown sends a shutdown event with the error envelope so
+// clients know to reconnect later rather than right away.
+func writeShutdown(w http.ResponseWriter) {
+	err := &tictactoe.ShuttingDownErr{}
+	data, _ := json.Marshal(tictactoe.ErrorResponse{
+		Code:    tictactoe.CodeOf(err),
+		Message: err.Error(),
+	})
+	fmt.Fprintf(w, "event: shutdown\ndata: %s\n\n", data)
+}
+
+func writeEvent(w http.ResponseWriter, state *tictactoe.GameState) error {
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\ndata: %s\n\n", state.Seq, data)
+	return err
+}
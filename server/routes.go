@@ -1,82 +1,338 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
-	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/go-chi/chi"
-	uuid "github.com/satori/go.uuid"
+	"github.com/gorilla/websocket"
 	"github.com/svolpe43/ttt/server/tictactoe"
 )
 
-const LongPollMaxWait = 30
-
 type Server interface {
 	Start()
 	ListGames(w http.ResponseWriter, r *http.Request)
 	JoinGame(w http.ResponseWriter, r *http.Request)
 	CreateGame(w http.ResponseWriter, r *http.Request)
+	Matchmake(w http.ResponseWriter, r *http.Request)
+	Register(w http.ResponseWriter, r *http.Request)
+	Profile(w http.ResponseWriter, r *http.Request)
+	Leaderboard(w http.ResponseWriter, r *http.Request)
+	ClaimSeat(w http.ResponseWriter, r *http.Request)
 	EndGame(w http.ResponseWriter, r *http.Request)
 	GetGame(w http.ResponseWriter, r *http.Request)
 	Move(w http.ResponseWriter, r *http.Request)
+	Resign(w http.ResponseWriter, r *http.Request)
+	Socket(w http.ResponseWriter, r *http.Request)
+	Watch(w http.ResponseWriter, r *http.Request)
+	Events(w http.ResponseWriter, r *http.Request)
+	History(w http.ResponseWriter, r *http.Request)
+	RequestTakeback(w http.ResponseWriter, r *http.Request)
+	AnswerTakeback(w http.ResponseWriter, r *http.Request)
+	Rematch(w http.ResponseWriter, r *http.Request)
+	Analyze(w http.ResponseWriter, r *http.Request)
 }
 
-func NewServer() Server {
-	return &server{
-		tictactoe: tictactoe.NewTicTacToe(),
+func NewServer(ttt tictactoe.TicTacToe, config *Config) Server {
+
+	ttt.SetMaxGames(config.MaxGames)
+
+	closing, stop := context.WithCancel(context.Background())
+
+	s := &server{
+		tictactoe: ttt,
+		config:    config,
+		closing:   closing,
+		stop:      stop,
 	}
+	s.upgrader = websocket.Upgrader{CheckOrigin: s.checkOrigin}
+	return s
 }
 
 type server struct {
 	tictactoe tictactoe.TicTacToe
+	config    *Config
+	upgrader  websocket.Upgrader
+
+	// closing is done once the server starts shutting down, which
+	// every request context derives from so open streams find out
+	closing context.Context
+	stop    context.CancelFunc
+	// sockets counts the open WebSockets, which the HTTP server
+	// forgets about once upgraded
+	sockets sync.WaitGroup
 }
 
+// Start serves the API until the process is told to stop with
+// SIGINT or SIGTERM, then gives open requests ShutdownTimeout to
+// finish.
 func (s *server) Start() {
 
+	srv := &http.Server{
+		Addr:         s.config.Addr,
+		Handler:      s.routes(),
+		ReadTimeout:  s.config.ReadTimeout,
+		WriteTimeout: s.config.WriteTimeout,
+		IdleTimeout:  s.config.IdleTimeout,
+		BaseContext: func(net.Listener) context.Context {
+			return s.closing
+		},
+	}
+
+	failed := make(chan error, 1)
+	go func() {
+		if s.config.TLS() {
+			fmt.Printf("Starting server at %s over HTTPS\n", s.config.Addr)
+			failed <- srv.ListenAndServeTLS(s.config.TLSCert, s.config.TLSKey)
+		} else {
+			fmt.Printf("Starting server at %s\n", s.config.Addr)
+			failed <- srv.ListenAndServe()
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case err := <-failed:
+		log.Fatal(err)
+	case sig := <-signals:
+		fmt.Printf("Received %s, shutting down\n", sig)
+	}
+
+	s.shutdown(srv)
+}
+
+// reservedIDs are the first path segments of routes that are not
+// about a game. A game named after one could not be reached.
+var reservedIDs = map[tictactoe.GameID]bool{
+	"players":     true,
+	"leaderboard": true,
+	"matchmake":   true,
+	"analyze":     true,
+}
+
+// routes is the API served by Start.
+func (s *server) routes() http.Handler {
+
 	r := chi.NewRouter()
 
 	r.Get("/", s.ListGames)
+	r.Post("/matchmake", s.Matchmake)
+	r.Get("/leaderboard", s.Leaderboard)
+	r.Get("/analyze/{hash}", s.Analyze)
+	r.Post("/players/{name}", s.Register)
+	r.Get("/players/{name}", s.Profile)
 	r.Post("/{id}/create/{symbol}", s.CreateGame)
+	r.Get("/{id}/ws", s.Socket)
+	r.Get("/{id}/watch", s.Watch)
+	r.Get("/{id}/events", s.Events)
+	r.Get("/{id}/history", s.History)
 	r.Get("/{id}/{hash}", s.GetGame)
 	r.Post("/{id}/join", s.JoinGame)
 	r.Post("/{id}/move/{symbol}/{index}", s.Move)
+	r.Post("/{id}/resign/{symbol}", s.Resign)
+	r.Post("/{id}/takeback/{symbol}", s.RequestTakeback)
+	r.Post("/{id}/takeback/{symbol}/{answer}", s.AnswerTakeback)
+	r.Post("/{id}/rematch/{symbol}", s.Rematch)
+	r.Post("/{id}/claim/{symbol}", s.ClaimSeat)
 	r.Delete("/{id}/end", s.EndGame)
 
-	fmt.Printf("Starting server at port 8080\n")
-	if err := http.ListenAndServe(":8080", r); err != nil {
-		log.Fatal(err)
+	return s.cors(r)
+}
+
+// shutdown tells the open streams the server is going away, stops
+// accepting requests and waits for the open ones to finish.
+func (s *server) shutdown(srv *http.Server) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+
+	s.stop()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("WARN: open requests did not finish: %v", err)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		s.sockets.Wait()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-ctx.Done():
+		log.Printf("WARN: WebSockets did not close in time")
+	}
+}
+
+// waitErr is the error for a wait that ended before anything
+// happened, telling a client whether to try again.
+func (s *server) waitErr() error {
+	if s.closing.Err() != nil {
+		return &tictactoe.ShuttingDownErr{}
 	}
+	return &tictactoe.TimeoutErr{}
 }
 
+// ListGames lists the games as JSON, oldest first. The status query
+// parameter picks waiting, open, playing or finished games and limit
+// and offset page through them.
 func (s *server) ListGames(w http.ResponseWriter, r *http.Request) {
 
-	games := s.tictactoe.ListGames()
+	filter := tictactoe.GameFilter{
+		Status: tictactoe.Status(r.URL.Query().Get("status")),
+	}
+
+	for param, value := range map[string]*int{
+		"limit":  &filter.Limit,
+		"offset": &filter.Offset,
+	} {
+		str := r.URL.Query().Get(param)
+		if str == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(str)
+		if err != nil {
+			badRequest(w, "Could not parse "+param)
+			return
+		}
+		*value = n
+	}
+
+	games, err := s.tictactoe.FindGames(filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(strings.Join(games, ",")))
+	json.NewEncoder(w).Encode(games)
 }
 
 func (s *server) CreateGame(w http.ResponseWriter, r *http.Request) {
 
 	gameID := tictactoe.GameID(chi.URLParam(r, "id"))
+	if reservedIDs[gameID] {
+		writeError(w, &tictactoe.ReservedGameIDErr{})
+		return
+	}
 
 	symbol := tictactoe.X
 	if chi.URLParam(r, "symbol") == string('o') {
 		symbol = tictactoe.O
 	}
 
-	if err := s.tictactoe.CreateGame(gameID, symbol); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+	opts, err := parseOptions(r)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	resp, err := s.tictactoe.CreateGame(gameID, symbol, opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// parseOptions reads the game options from the query parameters
+// ai, width, height, win, best_of, time, increment and per_move.
+func parseOptions(r *http.Request) (tictactoe.GameOptions, error) {
+
+	opts := tictactoe.GameOptions{
+		AI: tictactoe.Difficulty(r.URL.Query().Get("ai")),
+	}
+
+	// the board defaults to classic when no size is given
+	for param, value := range map[string]*int{
+		"width":   &opts.Width,
+		"height":  &opts.Height,
+		"win":     &opts.WinLength,
+		"best_of": &opts.BestOf,
+	} {
+		str := r.URL.Query().Get(param)
+		if str == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(str)
+		if err != nil {
+			return opts, &tictactoe.BadRequestErr{Reason: "Could not parse " + param}
+		}
+		*value = n
+	}
+
+	// times are given in whole seconds
+	for param, value := range map[string]*time.Duration{
+		"time":      &opts.TimeControl.Base,
+		"increment": &opts.TimeControl.Increment,
+		"per_move":  &opts.TimeControl.PerMove,
+	} {
+		str := r.URL.Query().Get(param)
+		if str == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(str)
+		if err != nil {
+			return opts, &tictactoe.BadRequestErr{Reason: "Could not parse " + param}
+		}
+		*value = time.Duration(n) * time.Second
+	}
+
+	return opts, nil
+}
+
+// MatchmakeMaxWait is how long a player waits in the matchmaking
+// queue before the request times out and has to be made again.
+const MatchmakeMaxWait = 60 * time.Second
+
+// Matchmake queues the player until someone asks for a game with the
+// same options, then responds with the seat in the new game. The
+// options are the query parameters of CreateGame.
+func (s *server) Matchmake(w http.ResponseWriter, r *http.Request) {
+
+	opts, err := parseOptions(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), MatchmakeMaxWait)
+	defer cancel()
+
+	resp, err := s.tictactoe.Matchmake(ctx, opts)
+	if err == context.DeadlineExceeded || err == context.Canceled {
+		writeError(w, s.waitErr())
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
 }
 
 func (s *server) JoinGame(w http.ResponseWriter, r *http.Request) {
@@ -85,8 +341,7 @@ func (s *server) JoinGame(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := s.tictactoe.JoinGame(gameID)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+		writeError(w, err)
 		return
 	}
 
@@ -99,9 +354,10 @@ func (s *server) EndGame(w http.ResponseWriter, r *http.Request) {
 
 	gameID := tictactoe.GameID(chi.URLParam(r, "id"))
 
-	if err := s.tictactoe.EndGame(gameID); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+	token := r.Header.Get(tictactoe.TokenHeader)
+
+	if err := s.tictactoe.EndGame(gameID, token); err != nil {
+		writeError(w, err)
 		return
 	}
 
@@ -112,19 +368,101 @@ func (s *server) Move(w http.ResponseWriter, r *http.Request) {
 
 	gameID := tictactoe.GameID(chi.URLParam(r, "id"))
 	symbol := chi.URLParam(r, "symbol")
+	token := r.Header.Get(tictactoe.TokenHeader)
 
 	indexStr := chi.URLParam(r, "index")
 	index, err := strconv.ParseInt(indexStr, 10, 0)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("Could not parse symbol"))
+		badRequest(w, "Could not parse index")
+		return
+	}
+
+	state, err := s.tictactoe.Move(gameID, tictactoe.Symbol(symbol), token, int(index))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(state)
+}
+
+func (s *server) Resign(w http.ResponseWriter, r *http.Request) {
+
+	gameID := tictactoe.GameID(chi.URLParam(r, "id"))
+	symbol := chi.URLParam(r, "symbol")
+	token := r.Header.Get(tictactoe.TokenHeader)
+
+	state, err := s.tictactoe.Resign(gameID, tictactoe.Symbol(symbol), token)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(state)
+}
+
+func (s *server) RequestTakeback(w http.ResponseWriter, r *http.Request) {
+
+	gameID := tictactoe.GameID(chi.URLParam(r, "id"))
+	symbol := chi.URLParam(r, "symbol")
+	token := r.Header.Get(tictactoe.TokenHeader)
+
+	state, err := s.tictactoe.RequestTakeback(gameID, tictactoe.Symbol(symbol), token)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(state)
+}
+
+// AnswerTakeback accepts or declines the opponent's takeback
+// request, the answer being either accept or decline.
+func (s *server) AnswerTakeback(w http.ResponseWriter, r *http.Request) {
+
+	gameID := tictactoe.GameID(chi.URLParam(r, "id"))
+	symbol := chi.URLParam(r, "symbol")
+	token := r.Header.Get(tictactoe.TokenHeader)
+
+	var accept bool
+	switch chi.URLParam(r, "answer") {
+	case "accept":
+		accept = true
+	case "decline":
+		accept = false
+	default:
+		badRequest(w, "Answer must be accept or decline")
+		return
+	}
+
+	state, err := s.tictactoe.AnswerTakeback(gameID, tictactoe.Symbol(symbol), token, accept)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
-	state, err := s.tictactoe.Move(gameID, tictactoe.Symbol(symbol), int(index))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(state)
+}
+
+// Rematch asks for another game once this one is over. The new game
+// starts when both players have asked.
+func (s *server) Rematch(w http.ResponseWriter, r *http.Request) {
+
+	gameID := tictactoe.GameID(chi.URLParam(r, "id"))
+	symbol := chi.URLParam(r, "symbol")
+	token := r.Header.Get(tictactoe.TokenHeader)
+
+	state, err := s.tictactoe.Rematch(gameID, tictactoe.Symbol(symbol), token)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+		writeError(w, err)
 		return
 	}
 
@@ -133,6 +471,124 @@ func (s *server) Move(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(state)
 }
 
+// Register creates a player account. The key in the response is
+// shown only once and proves the player owns the name.
+func (s *server) Register(w http.ResponseWriter, r *http.Request) {
+
+	account, err := s.tictactoe.Register(chi.URLParam(r, "name"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(account)
+}
+
+func (s *server) Profile(w http.ResponseWriter, r *http.Request) {
+
+	profile, err := s.tictactoe.Profile(chi.URLParam(r, "name"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(profile)
+}
+
+// LeaderboardSize is how many players the leaderboard shows when no
+// limit is given.
+const LeaderboardSize = 10
+
+// Leaderboard lists the best rated players, as many as the limit
+// query parameter asks for.
+func (s *server) Leaderboard(w http.ResponseWriter, r *http.Request) {
+
+	limit := LeaderboardSize
+	if str := r.URL.Query().Get("limit"); str != "" {
+		n, err := strconv.Atoi(str)
+		if err != nil {
+			badRequest(w, "Could not parse limit")
+			return
+		}
+		limit = n
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(s.tictactoe.Leaderboard(limit))
+}
+
+// ClaimSeat puts a player's name on the seat held with the player
+// token. The account is given by the name and key headers.
+func (s *server) ClaimSeat(w http.ResponseWriter, r *http.Request) {
+
+	gameID := tictactoe.GameID(chi.URLParam(r, "id"))
+	symbol := chi.URLParam(r, "symbol")
+	token := r.Header.Get(tictactoe.TokenHeader)
+	name := r.Header.Get(tictactoe.NameHeader)
+	key := r.Header.Get(tictactoe.KeyHeader)
+
+	state, err := s.tictactoe.ClaimSeat(gameID, tictactoe.Symbol(symbol), token, name, key)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(state)
+}
+
+func (s *server) History(w http.ResponseWriter, r *http.Request) {
+
+	gameID := tictactoe.GameID(chi.URLParam(r, "id"))
+
+	history, err := s.tictactoe.History(gameID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(history)
+}
+
+// Analyze solves the board encoded by the hash, see tictactoe.Hash.
+// The board is classic unless the width, height and win query
+// parameters say otherwise, and the turn parameter picks the player
+// to move when both have played as many cells.
+func (s *server) Analyze(w http.ResponseWriter, r *http.Request) {
+
+	board, err := tictactoe.ParseHash(chi.URLParam(r, "hash"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	opts, err := parseOptions(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	turn := tictactoe.Symbol(r.URL.Query().Get("turn"))
+
+	analysis, err := tictactoe.Analyze(opts.Shape, board, turn)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(analysis)
+}
+
 // GetGame is a long polling request that will listen to the
 // event stream of a particular game and respond with the result.
 func (s *server) GetGame(w http.ResponseWriter, r *http.Request) {
@@ -140,10 +596,20 @@ func (s *server) GetGame(w http.ResponseWriter, r *http.Request) {
 	gameID := tictactoe.GameID(chi.URLParam(r, "id"))
 	hash := chi.URLParam(r, "hash")
 
+	ctx := r.Context()
+
+	// Subscribe before reading the game so no event is missed
+	// in between. Only the latest state matters to a long poll.
+	sub, err := s.tictactoe.Subscribe(ctx, gameID, 1, tictactoe.DropOldest)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer sub.Unsubscribe()
+
 	game, err := s.tictactoe.GetGame(gameID)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+		writeError(w, err)
 		return
 	}
 
@@ -156,45 +622,48 @@ func (s *server) GetGame(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id := uuid.NewV4().String()
+	timeout := time.NewTimer(s.config.LongPollWait)
+	defer timeout.Stop()
 
-	stream, err := s.tictactoe.GameStream(gameID, id)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
-		return
-	}
-
-	ctx := r.Context()
-	timeout := time.NewTimer(LongPollMaxWait * time.Second)
-
-POLL:
 	for {
 		select {
 		case <-ctx.Done():
-			w.WriteHeader(http.StatusGatewayTimeout)
-			w.Write([]byte("Request context has timed out"))
-			break POLL
+			writeError(w, s.waitErr())
+			return
 		case <-timeout.C:
-			w.WriteHeader(http.StatusRequestTimeout)
-			w.Write([]byte("Long poll request timed out"))
-			break POLL
-		case state := <-stream:
+			writeError(w, &tictactoe.TimeoutErr{})
+			return
+		case state, ok := <-sub.Events():
+			if !ok {
+				writeError(w, &tictactoe.GameNotFoundErr{})
+				return
+			}
 
 			// throw away messages with the same hash
-			// these are sent from our moves
-			if tictactoe.Hash(state.Board) == hash {
+			// these are sent from our moves. Takeback and rematch
+			// requests leave the board alone but still need an
+			// answer from the other player, and a game can be lost
+			// without a move.
+			if tictactoe.Hash(state.Board) == hash && !isNotice(state.Event) {
 				continue
 			}
 
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusOK)
 			json.NewEncoder(w).Encode(state)
-			break POLL
+			return
 		}
 	}
+}
 
-	if err := s.tictactoe.DeleteStream(gameID, id); err != nil {
-		fmt.Println("WARN: could not delete stream")
+// isNotice reports whether a player must hear about the event even
+// though the board may not have changed.
+func isNotice(event tictactoe.EventType) bool {
+	switch event {
+	case tictactoe.TakebackRequestEvent, tictactoe.TakebackDeclinedEvent,
+		tictactoe.RematchRequestEvent, tictactoe.RematchEvent,
+		tictactoe.ResignEvent, tictactoe.TimeoutEvent, tictactoe.JoinEvent:
+		return true
 	}
+	return false
 }
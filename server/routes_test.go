@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/svolpe43/ttt/client"
+	"github.com/svolpe43/ttt/server/tictactoe"
+)
+
+func TestShutdown(t *testing.T) {
+
+	config := DefaultConfig()
+	config.ShutdownTimeout = 5 * time.Second
+	s := NewServer(tictactoe.NewTicTacToe(), config).(*server)
+
+	srv := httptest.NewUnstartedServer(s.routes())
+	srv.Config.BaseContext = func(net.Listener) context.Context {
+		return s.closing
+	}
+	srv.Start()
+	defer srv.Close()
+
+	x := client.New(client.WithHost(srv.URL))
+	ctx := context.Background()
+
+	created, err := x.CreateGame(ctx, "game", tictactoe.X, tictactoe.GameOptions{})
+	require.NoError(t, err)
+
+	// an open event stream is told the server is going away
+	stream, err := x.Subscribe(ctx, "game")
+	require.NoError(t, err)
+	state := <-stream.States()
+	require.Equal(t, created.State.ID, state.ID)
+
+	done := make(chan struct{})
+	go func() {
+		s.shutdown(srv.Config)
+		close(done)
+	}()
+
+	for range stream.States() {
+	}
+	err = stream.Err()
+	require.True(t, errors.As(err, new(*tictactoe.ShuttingDownErr)), "got %v", err)
+
+	select {
+	case <-done:
+	case <-time.After(config.ShutdownTimeout):
+		t.Fatal("shutdown did not finish")
+	}
+
+	// nothing new is accepted
+	_, err = x.GetGame(ctx, "game")
+	require.Error(t, err)
+}
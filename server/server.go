@@ -1,11 +1,39 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"log"
+	"os"
+
+	"github.com/svolpe43/ttt/server/tictactoe"
 )
 
 func main() {
+
+	config, err := LoadConfig(os.Args[1:], os.Getenv)
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ttt := tictactoe.NewTicTacToe()
+
+	if config.DataDir != "" {
+		store, err := tictactoe.NewFileStore(config.DataDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		ttt, err = tictactoe.NewTicTacToeFromStore(store)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	fmt.Println("Tic Tac Toe Server has started.")
-	s := NewServer()
+	s := NewServer(ttt, config)
 	s.Start()
 }
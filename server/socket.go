@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/gorilla/websocket"
+	"github.com/svolpe43/ttt/server/tictactoe"
+)
+
+// socket serialises writes to a websocket connection, which
+// only supports one concurrent writer.
+type socket struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+func (s *socket) write(msg tictactoe.ServerMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn.WriteJSON(msg)
+}
+
+func (s *socket) writeError(err error) error {
+	return s.write(tictactoe.ServerMessage{
+		Type:  tictactoe.ErrorMessage,
+		Error: err.Error(),
+		Code:  tictactoe.CodeOf(err),
+	})
+}
+
+// goingAway tells the client the server is shutting down before the
+// connection is closed.
+func (s *socket) goingAway() {
+	err := &tictactoe.ShuttingDownErr{}
+	if s.writeError(err) != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseGoingAway, err.Error()),
+		time.Now().Add(time.Second))
+}
+
+// Socket streams every state of a game over a WebSocket and plays
+// the messages received from it. The seat is chosen with the symbol
+// query parameter and authenticated by the player token, passed in
+// the token header or the token query parameter since browsers
+// cannot set headers on WebSockets. Without a token the socket is
+// read only and counted as a spectator. See tictactoe.ClientMessage
+// for the message schema.
+func (s *server) Socket(w http.ResponseWriter, r *http.Request) {
+
+	symbol := tictactoe.Symbol(r.URL.Query().Get("symbol"))
+
+	token := r.Header.Get(tictactoe.TokenHeader)
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+
+	// refuse a bad seat before upgrading, rather than on every move
+	if token != "" && !symbol.Valid() {
+		writeError(w, &tictactoe.InvalidSymbolErr{})
+		return
+	}
+
+	s.serveSocket(w, r, symbol, token)
+}
+
+// Watch streams every state of a game over a read only WebSocket
+// without taking a seat.
+func (s *server) Watch(w http.ResponseWriter, r *http.Request) {
+	s.serveSocket(w, r, tictactoe.Empty, "")
+}
+
+func (s *server) serveSocket(w http.ResponseWriter, r *http.Request, symbol tictactoe.Symbol, token string) {
+
+	gameID := tictactoe.GameID(chi.URLParam(r, "id"))
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has already responded
+		return
+	}
+	defer conn.Close()
+
+	s.sockets.Add(1)
+	defer s.sockets.Done()
+
+	sock := &socket{conn: conn}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// disconnect sockets that cannot keep up rather than
+	// silently skipping states
+	subscribe := s.tictactoe.Subscribe
+	if token == "" {
+		subscribe = s.tictactoe.Watch
+	}
+
+	sub, err := subscribe(ctx, gameID, tictactoe.DefaultBuffer, tictactoe.Disconnect)
+	if err != nil {
+		sock.writeError(err)
+		return
+	}
+
+	state, err := s.tictactoe.GetGame(gameID)
+	if err != nil {
+		sock.writeError(err)
+		return
+	}
+
+	if err := sock.write(tictactoe.ServerMessage{
+		Type:  tictactoe.StateMessage,
+		State: state,
+	}); err != nil {
+		return
+	}
+
+	go func() {
+		for state := range sub.Events() {
+			state := state
+			if err := sock.write(tictactoe.ServerMessage{
+				Type:  tictactoe.StateMessage,
+				State: &state,
+			}); err != nil {
+				break
+			}
+		}
+
+		if s.closing.Err() != nil {
+			sock.goingAway()
+		}
+
+		// the game ended, we fell behind or the server is shutting
+		// down, unblock the reader
+		conn.Close()
+	}()
+
+	for {
+		msg := tictactoe.ClientMessage{}
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+
+		switch msg.Type {
+		case tictactoe.MoveMessage:
+			_, err = s.tictactoe.Move(gameID, symbol, token, msg.Index)
+		case tictactoe.ResignMessage:
+			_, err = s.tictactoe.Resign(gameID, symbol, token)
+		case tictactoe.ChatMessage:
+			err = s.tictactoe.Chat(gameID, symbol, token, msg.Text)
+		case tictactoe.TakebackMessage:
+			_, err = s.tictactoe.RequestTakeback(gameID, symbol, token)
+		case tictactoe.AcceptMessage, tictactoe.DeclineMessage:
+			_, err = s.tictactoe.AnswerTakeback(gameID, symbol, token, msg.Type == tictactoe.AcceptMessage)
+		case tictactoe.RematchMessage:
+			_, err = s.tictactoe.Rematch(gameID, symbol, token)
+		default:
+			err = &tictactoe.UnknownMessageErr{}
+		}
+
+		// successful messages are answered by the published state
+		if err != nil {
+			if err := sock.writeError(err); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package tictactoe
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// InitialRating is the Elo rating of a new player.
+	InitialRating = 1200
+	// RatingK is the most a rating can change after one game.
+	RatingK = 32
+)
+
+// Profile is the public record of a named player.
+type Profile struct {
+	Name    string    `json:"name"`
+	Rating  int       `json:"rating"`
+	Wins    int       `json:"wins"`
+	Losses  int       `json:"losses"`
+	Draws   int       `json:"draws"`
+	Created time.Time `json:"created"`
+}
+
+// Account is handed to a player when they register. Key proves the
+// player owns the name and is never shown again.
+type Account struct {
+	Profile
+	Key string `json:"key"`
+}
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// accounts holds every registered player. Games rate their players
+// while holding their own lock, so accounts never takes a game lock.
+type accounts struct {
+	mu      sync.Mutex
+	records map[string]*AccountRecord
+	store   Store
+}
+
+func newAccounts(store Store) (*accounts, error) {
+
+	records, err := store.ListAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	a := &accounts{
+		records: map[string]*AccountRecord{},
+		store:   store,
+	}
+	for _, record := range records {
+		a.records[record.Name] = record
+	}
+	return a, nil
+}
+
+// Register creates an account for the name.
+func (t *ttt) Register(name string) (*Account, error) {
+
+	if !validName.MatchString(name) {
+		return nil, &InvalidNameErr{}
+	}
+
+	a := t.accounts
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.records[name]; ok {
+		return nil, &AccountExistsErr{}
+	}
+
+	key := uuid.NewV4().String()
+	record := &AccountRecord{
+		Profile: Profile{
+			Name:    name,
+			Rating:  InitialRating,
+			Created: time.Now(),
+		},
+		KeyHash: hashKey(key),
+	}
+
+	if err := a.store.SaveAccount(record); err != nil {
+		return nil, err
+	}
+	a.records[name] = record
+
+	return &Account{
+		Profile: record.Profile,
+		Key:     key,
+	}, nil
+}
+
+// Profile returns a player's profile or AccountNotFoundErr.
+func (t *ttt) Profile(name string) (*Profile, error) {
+
+	a := t.accounts
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	record, ok := a.records[name]
+	if !ok {
+		return nil, &AccountNotFoundErr{}
+	}
+
+	profile := record.Profile
+	return &profile, nil
+}
+
+// Leaderboard returns up to limit players, best rated first.
+func (t *ttt) Leaderboard(limit int) []Profile {
+
+	a := t.accounts
+	a.mu.Lock()
+	profiles := []Profile{}
+	for _, record := range a.records {
+		profiles = append(profiles, record.Profile)
+	}
+	a.mu.Unlock()
+
+	sort.Slice(profiles, func(i, j int) bool {
+		if profiles[i].Rating != profiles[j].Rating {
+			return profiles[i].Rating > profiles[j].Rating
+		}
+		return profiles[i].Name < profiles[j].Name
+	})
+
+	if limit > 0 && len(profiles) > limit {
+		profiles = profiles[:limit]
+	}
+	return profiles
+}
+
+// ClaimSeat puts a player's name on the seat they hold, so the game
+// counts towards their record and rating. A seat can only be claimed
+// before it has moved, and games are only rated once both seats are
+// claimed by different players.
+func (t *ttt) ClaimSeat(id GameID, symbol Symbol, token, name, key string) (*GameState, error) {
+
+	if err := t.accounts.check(name, key); err != nil {
+		return nil, err
+	}
+
+	game, err := t.game(id)
+	if err != nil {
+		return nil, err
+	}
+
+	game.mu.Lock()
+	defer game.mu.Unlock()
+
+	if err := game.checkSeat(symbol, token); err != nil {
+		return nil, err
+	}
+
+	if game.status.Over() || game.lastMove(symbol) >= 0 {
+		return nil, &SeatInPlayErr{}
+	}
+
+	if game.players[opponent(symbol)] == name {
+		return nil, &SamePlayerErr{}
+	}
+
+	game.players[symbol] = name
+
+	return game.publish(ClaimEvent), nil
+}
+
+// check makes sure key is the secret of the named account.
+func (a *accounts) check(name, key string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	record, ok := a.records[name]
+	if !ok {
+		return &AccountNotFoundErr{}
+	}
+	if subtle.ConstantTimeCompare([]byte(record.KeyHash), []byte(hashKey(key))) != 1 {
+		return &InvalidTokenErr{}
+	}
+	return nil
+}
+
+// rate records the result of a game between two players and moves
+// their ratings towards it.
+func (a *accounts) rate(x, o string, result Result, winner Symbol) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	px, ok := a.records[x]
+	if !ok {
+		return
+	}
+	po, ok := a.records[o]
+	if !ok {
+		return
+	}
+
+	// the score of X, one for a win and a half for a draw
+	score := 0.5
+	switch {
+	case result == Drawn:
+		px.Draws++
+		po.Draws++
+	case winner == X:
+		score = 1
+		px.Wins++
+		po.Losses++
+	default:
+		score = 0
+		px.Losses++
+		po.Wins++
+	}
+
+	change := ratingChange(px.Rating, po.Rating, score)
+	px.Rating += change
+	po.Rating -= change
+
+	for _, record := range []*AccountRecord{px, po} {
+		if err := a.store.SaveAccount(record); err != nil {
+			log.Printf("WARN: could not save player %s: %v", record.Name, err)
+		}
+	}
+}
+
+// ratingChange is how much the Elo rating of a player rated a goes
+// up after scoring score, one for a win and a half for a draw,
+// against a player rated b. The other player's rating goes down by
+// as much.
+func ratingChange(a, b int, score float64) int {
+	expected := 1 / (1 + math.Pow(10, float64(b-a)/400))
+	return int(math.Round(RatingK * (score - expected)))
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
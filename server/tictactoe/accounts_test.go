@@ -0,0 +1,126 @@
+package tictactoe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	alice, err := ttt.Register("alice")
+	require.NoError(t, err)
+	require.Equal(t, InitialRating, alice.Rating)
+	require.NotEmpty(t, alice.Key)
+
+	_, err = ttt.Register("alice")
+	require.IsType(t, &AccountExistsErr{}, err)
+
+	for _, name := range []string{"", "has space", "a/b", "averyveryveryverylongnamethatgoesonandon"} {
+		_, err = ttt.Register(name)
+		require.IsType(t, &InvalidNameErr{}, err, name)
+	}
+
+	profile, err := ttt.Profile("alice")
+	require.NoError(t, err)
+	require.Equal(t, alice.Profile, *profile)
+
+	_, err = ttt.Profile("bob")
+	require.IsType(t, &AccountNotFoundErr{}, err)
+}
+
+func TestRatedGame(t *testing.T) {
+
+	store := NewMemoryStore()
+
+	ttt, err := NewTicTacToeFromStore(store)
+	require.NoError(t, err)
+
+	alice, err := ttt.Register("alice")
+	require.NoError(t, err)
+	bob, err := ttt.Register("bob")
+	require.NoError(t, err)
+
+	x, err := ttt.CreateGame("rated", X, GameOptions{BestOf: 3})
+	require.NoError(t, err)
+	o, err := ttt.JoinGame("rated")
+	require.NoError(t, err)
+
+	_, err = ttt.ClaimSeat("rated", X, x.Token, "alice", bob.Key)
+	require.IsType(t, &InvalidTokenErr{}, err)
+
+	state, err := ttt.ClaimSeat("rated", X, x.Token, "alice", alice.Key)
+	require.NoError(t, err)
+	require.Equal(t, ClaimEvent, state.Event)
+	require.Equal(t, map[Symbol]string{X: "alice"}, state.Players)
+
+	_, err = ttt.ClaimSeat("rated", O, o.Token, "alice", alice.Key)
+	require.IsType(t, &SamePlayerErr{}, err)
+
+	_, err = ttt.Move("rated", X, x.Token, 4)
+	require.NoError(t, err)
+
+	// X has moved so the seat cannot change hands
+	_, err = ttt.ClaimSeat("rated", X, x.Token, "alice", alice.Key)
+	require.IsType(t, &SeatInPlayErr{}, err)
+
+	_, err = ttt.ClaimSeat("rated", O, o.Token, "bob", bob.Key)
+	require.NoError(t, err)
+
+	_, err = ttt.Resign("rated", O, o.Token)
+	require.NoError(t, err)
+
+	winner, err := ttt.Profile("alice")
+	require.NoError(t, err)
+	require.Equal(t, InitialRating+RatingK/2, winner.Rating)
+	require.Equal(t, 1, winner.Wins)
+
+	loser, err := ttt.Profile("bob")
+	require.NoError(t, err)
+	require.Equal(t, InitialRating-RatingK/2, loser.Rating)
+	require.Equal(t, 1, loser.Losses)
+
+	// the rematch is rated too and the underdog gains more for a draw
+	_, err = ttt.Rematch("rated", X, x.Token)
+	require.NoError(t, err)
+	_, err = ttt.Rematch("rated", O, o.Token)
+	require.NoError(t, err)
+
+	for i, index := range []int{0, 1, 2, 4, 3, 6, 7, 5, 8} {
+		symbol := []Symbol{O, X}[i%2]
+		token := x.Token
+		if symbol == O {
+			token = o.Token
+		}
+		_, err = ttt.Move("rated", symbol, token, index)
+		require.NoError(t, err)
+	}
+
+	state, err = ttt.GetGame("rated")
+	require.NoError(t, err)
+	require.Equal(t, Drawn, state.Result)
+
+	loser, err = ttt.Profile("bob")
+	require.NoError(t, err)
+	require.Equal(t, 1, loser.Draws)
+	require.True(t, loser.Rating > InitialRating-RatingK/2)
+
+	board := ttt.Leaderboard(0)
+	require.Len(t, board, 2)
+	require.Equal(t, "alice", board[0].Name)
+	require.Len(t, ttt.Leaderboard(1), 1)
+
+	// accounts and claimed seats survive a restart
+	ttt, err = NewTicTacToeFromStore(store)
+	require.NoError(t, err)
+
+	profile, err := ttt.Profile("bob")
+	require.NoError(t, err)
+	require.Equal(t, loser, profile)
+
+	state, err = ttt.GetGame("rated")
+	require.NoError(t, err)
+	require.Equal(t, map[Symbol]string{X: "alice", O: "bob"}, state.Players)
+}
@@ -0,0 +1,128 @@
+package tictactoe
+
+// MaxBoardSize is the largest width or height of a board. It keeps
+// every column addressable by a single letter.
+const MaxBoardSize = 26
+
+// Shape describes an m,n,k board: Width by Height cells where
+// WinLength symbols in a row, column or diagonal win. Cells are
+// indexed left to right and top to bottom.
+type Shape struct {
+	Width     int `json:"width"`
+	Height    int `json:"height"`
+	WinLength int `json:"win_length"`
+}
+
+// Classic is the 3x3 board with three in a row to win.
+var Classic = Shape{Width: 3, Height: 3, WinLength: 3}
+
+// directions a line can run in, as column and row steps
+var directions = [][2]int{{1, 0}, {0, 1}, {1, 1}, {1, -1}}
+
+// Cells returns the number of cells on the board.
+func (s Shape) Cells() int {
+	return s.Width * s.Height
+}
+
+// Valid reports whether the board can be played on.
+func (s Shape) Valid() error {
+	if s.Width < 1 || s.Width > MaxBoardSize || s.Height < 1 || s.Height > MaxBoardSize {
+		return &InvalidBoardErr{}
+	}
+	if s.WinLength < 2 || (s.WinLength > s.Width && s.WinLength > s.Height) {
+		return &InvalidBoardErr{}
+	}
+	return nil
+}
+
+// Contains reports whether index is a cell of the board.
+func (s Shape) Contains(index int) bool {
+	return index >= 0 && index < s.Cells()
+}
+
+// IsWonAt reports whether the symbol at index is part of a winning
+// line. Only the lines through index are checked, which makes it the
+// cheap check after a move.
+func (s Shape) IsWonAt(board []Symbol, symbol Symbol, index int) bool {
+
+	col, row := index%s.Width, index/s.Width
+
+	for _, d := range directions {
+		count := 1 + s.run(board, symbol, col, row, d[0], d[1]) + s.run(board, symbol, col, row, -d[0], -d[1])
+		if count >= s.WinLength {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsWon reports whether symbol has a winning line anywhere on the board.
+func (s Shape) IsWon(board []Symbol, symbol Symbol) bool {
+	for i, v := range board {
+		if v == symbol && s.IsWonAt(board, symbol, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// run counts the symbols in a row from col, row in one direction,
+// not including the starting cell.
+func (s Shape) run(board []Symbol, symbol Symbol, col, row, dc, dr int) int {
+	n := 0
+	for {
+		col, row = col+dc, row+dr
+		if col < 0 || col >= s.Width || row < 0 || row >= s.Height {
+			return n
+		}
+		if board[row*s.Width+col] != symbol {
+			return n
+		}
+		n++
+	}
+}
+
+// centerDistance is the squared distance of index from the center
+// of the board, scaled by four to stay in whole numbers.
+func (s Shape) centerDistance(index int) int {
+	dc := 2*(index%s.Width) - (s.Width - 1)
+	dr := 2*(index/s.Width) - (s.Height - 1)
+	return dc*dc + dr*dr
+}
+
+// openLines counts the winning lines through index that the
+// opponent of symbol has not blocked yet.
+func (s Shape) openLines(board []Symbol, symbol Symbol, index int) int {
+
+	col, row := index%s.Width, index/s.Width
+	other := opponent(symbol)
+	open := 0
+
+	for _, d := range directions {
+
+		// every window of WinLength cells along d covering index
+		for offset := 0; offset < s.WinLength; offset++ {
+			startCol, startRow := col-offset*d[0], row-offset*d[1]
+			endCol, endRow := startCol+(s.WinLength-1)*d[0], startRow+(s.WinLength-1)*d[1]
+
+			if startCol < 0 || startCol >= s.Width || startRow < 0 || startRow >= s.Height ||
+				endCol < 0 || endCol >= s.Width || endRow < 0 || endRow >= s.Height {
+				continue
+			}
+
+			blocked := false
+			for i := 0; i < s.WinLength; i++ {
+				if board[(startRow+i*d[1])*s.Width+startCol+i*d[0]] == other {
+					blocked = true
+					break
+				}
+			}
+			if !blocked {
+				open++
+			}
+		}
+	}
+
+	return open
+}
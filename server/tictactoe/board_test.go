@@ -0,0 +1,100 @@
+package tictactoe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShapeValid(t *testing.T) {
+
+	tests := []struct {
+		shape Shape
+		valid bool
+	}{
+		{Classic, true},
+		{Shape{Width: 15, Height: 15, WinLength: 5}, true},
+		{Shape{Width: 7, Height: 6, WinLength: 4}, true},
+		{Shape{Width: 1, Height: 5, WinLength: 5}, true},
+		{Shape{Width: 0, Height: 3, WinLength: 3}, false},
+		{Shape{Width: 3, Height: 3, WinLength: 4}, false},
+		{Shape{Width: 3, Height: 3, WinLength: 1}, false},
+		{Shape{Width: MaxBoardSize + 1, Height: 3, WinLength: 3}, false},
+	}
+
+	for _, test := range tests {
+		err := test.shape.Valid()
+		if test.valid {
+			require.NoError(t, err, "%+v", test.shape)
+		} else {
+			require.IsType(t, &InvalidBoardErr{}, err, "%+v", test.shape)
+		}
+	}
+}
+
+func TestShapeIsWon(t *testing.T) {
+
+	shape := Shape{Width: 4, Height: 4, WinLength: 4}
+
+	tests := []struct {
+		cells []int
+		won   bool
+	}{
+		{[]int{0, 1, 2, 3}, true},
+		{[]int{1, 5, 9, 13}, true},
+		{[]int{0, 5, 10, 15}, true},
+		{[]int{3, 6, 9, 12}, true},
+		{[]int{0, 1, 2}, false},
+		{[]int{2, 3, 4, 5}, false},
+		{[]int{0, 5, 10, 14}, false},
+	}
+
+	for _, test := range tests {
+		board := make([]Symbol, shape.Cells())
+		for _, i := range test.cells {
+			board[i] = X
+		}
+
+		require.Equal(t, test.won, shape.IsWon(board, X), "%v", test.cells)
+		last := test.cells[len(test.cells)-1]
+		require.Equal(t, test.won, shape.IsWonAt(board, X, last), "%v", test.cells)
+	}
+}
+
+func TestLargeGame(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	_, err := ttt.CreateGame("huge", X, GameOptions{Shape: Shape{Width: 30, Height: 30, WinLength: 5}})
+	require.IsType(t, &InvalidBoardErr{}, err)
+
+	shape := Shape{Width: 15, Height: 15, WinLength: 5}
+
+	x, err := ttt.CreateGame("gomoku", X, GameOptions{Shape: shape})
+	require.NoError(t, err)
+	require.Equal(t, shape, x.State.Shape)
+	require.Len(t, x.State.Board, 225)
+
+	o, err := ttt.JoinGame("gomoku")
+	require.NoError(t, err)
+
+	_, err = ttt.Move("gomoku", X, x.Token, 225)
+	require.IsType(t, &OffBoardErr{}, err)
+
+	_, err = ttt.Move("gomoku", X, x.Token, -1)
+	require.IsType(t, &OffBoardErr{}, err)
+
+	var state *GameState
+	for i := 0; i < 5; i++ {
+		state, err = ttt.Move("gomoku", X, x.Token, 112+i)
+		require.NoError(t, err)
+
+		if i < 4 {
+			_, err = ttt.Move("gomoku", O, o.Token, i)
+			require.NoError(t, err)
+		}
+	}
+
+	require.Equal(t, Won, state.Result)
+	require.Equal(t, X, state.Winner)
+}
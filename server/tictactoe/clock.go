@@ -0,0 +1,174 @@
+package tictactoe
+
+import (
+	"time"
+)
+
+// TimeControl limits how long the players may think. With PerMove
+// set every move must be made within PerMove, otherwise each player
+// has Base for the whole game and gains Increment after every move.
+// The zero TimeControl is an untimed game.
+type TimeControl struct {
+	Base      time.Duration `json:"base"`
+	Increment time.Duration `json:"increment"`
+	PerMove   time.Duration `json:"per_move"`
+}
+
+// Untimed reports whether the game has no clock.
+func (tc TimeControl) Untimed() bool {
+	return tc == TimeControl{}
+}
+
+// Valid reports whether the time control can be played with.
+func (tc TimeControl) Valid() error {
+	if tc.Base < 0 || tc.Increment < 0 || tc.PerMove < 0 {
+		return &InvalidTimeControlErr{}
+	}
+	// a move time replaces the game time
+	if tc.PerMove > 0 && (tc.Base > 0 || tc.Increment > 0) {
+		return &InvalidTimeControlErr{}
+	}
+	if tc.Increment > 0 && tc.Base == 0 {
+		return &InvalidTimeControlErr{}
+	}
+	return nil
+}
+
+// Clock is the time each player had left when a state was taken.
+// The time of the running player keeps going down after that.
+type Clock struct {
+	TimeControl
+	Remaining map[Symbol]time.Duration `json:"remaining"`
+	// Running is the player whose time is going down, if any
+	Running Symbol `json:"running,omitempty"`
+}
+
+// Left returns the time the symbol has left, counting down from the
+// state being taken at since.
+func (c *Clock) Left(symbol Symbol, since time.Time) time.Duration {
+	left := c.Remaining[symbol]
+	if c.Running == symbol {
+		left -= time.Since(since)
+	}
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// fullTime is the time each player starts a game with.
+func (tc TimeControl) fullTime() map[Symbol]time.Duration {
+	start := tc.Base
+	if tc.PerMove > 0 {
+		start = tc.PerMove
+	}
+	return map[Symbol]time.Duration{X: start, O: start}
+}
+
+// clockRunning reports whether the player to move is losing time,
+// which is while the game is being played.
+func (g *game) clockRunning() bool {
+	return !g.control.Untimed() && g.status == Playing
+}
+
+// startClock starts the time of the player to move, if the clock is
+// running, and arms the timer that makes them lose when it runs out.
+// The game lock must be held.
+func (g *game) startClock() {
+
+	g.stopClock()
+
+	if !g.clockRunning() {
+		return
+	}
+
+	if g.control.PerMove > 0 {
+		g.remaining[g.turn] = g.control.PerMove
+	}
+
+	g.started = time.Now()
+
+	gen := g.clockGen
+	g.timer = time.AfterFunc(g.remaining[g.turn], func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		// the clock was stopped or restarted since
+		if gen != g.clockGen {
+			return
+		}
+		g.flag()
+	})
+}
+
+// stopClock charges the player to move for the time they have
+// used. It does nothing when the clock is not running. The game lock
+// must be held.
+func (g *game) stopClock() {
+
+	g.clockGen++
+
+	if g.timer != nil {
+		g.timer.Stop()
+		g.timer = nil
+	}
+
+	if g.started.IsZero() {
+		return
+	}
+
+	g.remaining[g.turn] -= time.Since(g.started)
+	if g.remaining[g.turn] < 0 {
+		g.remaining[g.turn] = 0
+	}
+	g.started = time.Time{}
+}
+
+// punchClock stops the clock after symbol has moved and adds the
+// increment to their time.
+func (g *game) punchClock(symbol Symbol) {
+
+	running := !g.started.IsZero()
+	g.stopClock()
+
+	if running {
+		g.remaining[symbol] += g.control.Increment
+	}
+}
+
+// outOfTime reports whether the player to move has run out of time
+// before the timer got to end the game.
+func (g *game) outOfTime() bool {
+	return !g.started.IsZero() && time.Since(g.started) >= g.remaining[g.turn]
+}
+
+// flag ends the game as a loss for the player to move, whose time
+// has run out.
+func (g *game) flag() *GameState {
+	g.stopClock()
+	g.remaining[g.turn] = 0
+	return g.finish(WonStatus, opponent(g.turn), TimeoutEvent)
+}
+
+// clock snapshots the time left for a state.
+func (g *game) clock() *Clock {
+
+	if g.control.Untimed() {
+		return nil
+	}
+
+	clock := &Clock{
+		TimeControl: g.control,
+		Remaining:   map[Symbol]time.Duration{},
+	}
+	for k, v := range g.remaining {
+		clock.Remaining[k] = v
+	}
+
+	if !g.started.IsZero() {
+		clock.Running = g.turn
+		clock.Remaining[g.turn] = clock.Left(g.turn, g.started)
+	}
+
+	return clock
+}
@@ -0,0 +1,125 @@
+package tictactoe
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeControlValid(t *testing.T) {
+
+	for _, tc := range []TimeControl{
+		{Base: -time.Second},
+		{Increment: time.Second},
+		{Base: time.Minute, PerMove: time.Second},
+	} {
+		_, err := NewTicTacToe().CreateGame("bad", X, GameOptions{TimeControl: tc})
+		require.IsType(t, &InvalidTimeControlErr{}, err, "%+v", tc)
+	}
+
+	resp, err := NewTicTacToe().CreateGame("untimed", X, GameOptions{})
+	require.NoError(t, err)
+	require.Nil(t, resp.State.Clock)
+}
+
+func TestClockIncrement(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	x, err := ttt.CreateGame("timed", X, GameOptions{
+		TimeControl: TimeControl{Base: time.Minute, Increment: 2 * time.Second},
+	})
+	require.NoError(t, err)
+
+	// nobody loses time waiting for an opponent
+	require.Equal(t, Empty, x.State.Clock.Running)
+	require.Equal(t, time.Minute, x.State.Clock.Remaining[X])
+
+	o, err := ttt.JoinGame("timed")
+	require.NoError(t, err)
+	require.Equal(t, X, o.State.Clock.Running)
+
+	state, err := ttt.Move("timed", X, x.Token, 4)
+	require.NoError(t, err)
+	require.Equal(t, O, state.Clock.Running)
+	require.True(t, state.Clock.Remaining[X] > time.Minute)
+	require.True(t, state.Clock.Remaining[X] <= time.Minute+2*time.Second)
+
+	state, err = ttt.Resign("timed", O, o.Token)
+	require.NoError(t, err)
+	require.Equal(t, Empty, state.Clock.Running)
+}
+
+func TestClockTimeout(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	x, err := ttt.CreateGame("flag", X, GameOptions{
+		TimeControl: TimeControl{PerMove: 100 * time.Millisecond},
+	})
+	require.NoError(t, err)
+
+	sub, err := ttt.Subscribe(context.Background(), "flag", 0, DropOldest)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	o, err := ttt.JoinGame("flag")
+	require.NoError(t, err)
+
+	_, err = ttt.Move("flag", X, x.Token, 0)
+	require.NoError(t, err)
+
+	// a move resets the time per move
+	state, err := ttt.Move("flag", O, o.Token, 4)
+	require.NoError(t, err)
+	require.True(t, state.Clock.Remaining[X] > 50*time.Millisecond)
+
+	timeout := time.After(time.Second)
+	for state.Event != TimeoutEvent {
+		select {
+		case s := <-sub.Events():
+			state = &s
+		case <-timeout:
+			t.Fatal("the clock never ran out")
+		}
+	}
+
+	require.Equal(t, Won, state.Result)
+	require.Equal(t, O, state.Winner)
+	require.Equal(t, time.Duration(0), state.Clock.Remaining[X])
+	require.Equal(t, 1, state.Series.Wins[O])
+
+	_, err = ttt.Move("flag", X, x.Token, 8)
+	require.IsType(t, &GameOverErr{}, err)
+}
+
+func TestClockRestore(t *testing.T) {
+
+	store := NewMemoryStore()
+
+	ttt, err := NewTicTacToeFromStore(store)
+	require.NoError(t, err)
+
+	x, err := ttt.CreateGame("timed", X, GameOptions{
+		TimeControl: TimeControl{Base: time.Minute},
+	})
+	require.NoError(t, err)
+	_, err = ttt.JoinGame("timed")
+	require.NoError(t, err)
+	_, err = ttt.Move("timed", X, x.Token, 4)
+	require.NoError(t, err)
+
+	before, err := ttt.GetGame("timed")
+	require.NoError(t, err)
+
+	ttt, err = NewTicTacToeFromStore(store)
+	require.NoError(t, err)
+
+	after, err := ttt.GetGame("timed")
+	require.NoError(t, err)
+	require.Equal(t, O, after.Clock.Running)
+	require.Equal(t, before.Clock.Remaining[X], after.Clock.Remaining[X])
+	require.Equal(t, time.Minute, after.Clock.TimeControl.Base)
+}
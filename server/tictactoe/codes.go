@@ -0,0 +1,153 @@
+package tictactoe
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorCode identifies an error in API responses. Codes are stable,
+// unlike the error messages.
+type ErrorCode string
+
+const (
+	// the codes of the errors of this package, each error returns
+	// its own from its Code method
+	GameNotFoundCode       ErrorCode = "game_not_found"
+	GameExistsCode         ErrorCode = "game_exists"
+	NotYourTurnCode        ErrorCode = "not_your_turn"
+	TooManyPlayersCode     ErrorCode = "too_many_players"
+	IllegalMoveCode        ErrorCode = "illegal_move"
+	InvalidTokenCode       ErrorCode = "invalid_token"
+	GameOverCode           ErrorCode = "game_over"
+	UnknownMessageCode     ErrorCode = "unknown_message"
+	UnknownDifficultyCode  ErrorCode = "unknown_difficulty"
+	InvalidBoardCode       ErrorCode = "invalid_board"
+	NoTakebackCode         ErrorCode = "no_takeback"
+	NothingToTakeBackCode  ErrorCode = "nothing_to_take_back"
+	InvalidSeriesCode      ErrorCode = "invalid_series"
+	GameNotOverCode        ErrorCode = "game_not_over"
+	SeriesOverCode         ErrorCode = "series_over"
+	InvalidTimeControlCode ErrorCode = "invalid_time_control"
+	InvalidFilterCode      ErrorCode = "invalid_filter"
+	MatchAICode            ErrorCode = "match_ai"
+	InvalidNameCode        ErrorCode = "invalid_name"
+	ReservedGameIDCode     ErrorCode = "reserved_game_id"
+	AccountExistsCode      ErrorCode = "account_exists"
+	AccountNotFoundCode    ErrorCode = "account_not_found"
+	SeatInPlayCode         ErrorCode = "seat_in_play"
+	SamePlayerCode         ErrorCode = "same_player"
+	InvalidSymbolCode      ErrorCode = "invalid_symbol"
+	OffBoardCode           ErrorCode = "off_board"
+	GameNotStartedCode     ErrorCode = "game_not_started"
+	TooManyGamesCode       ErrorCode = "too_many_games"
+	ShuttingDownCode       ErrorCode = "shutting_down"
+	UnknownStrategyCode    ErrorCode = "unknown_strategy"
+	TooFewStrategiesCode   ErrorCode = "too_few_strategies"
+	NoRoundsCode           ErrorCode = "no_rounds"
+	DuplicateStrategyCode  ErrorCode = "duplicate_strategy"
+	InvalidPositionCode    ErrorCode = "invalid_position"
+	TooLargeToSolveCode    ErrorCode = "too_large_to_solve"
+
+	// BadRequestCode is a request that could not be understood
+	BadRequestCode ErrorCode = "bad_request"
+	// TimeoutCode is a wait that ran out before anything happened
+	TimeoutCode ErrorCode = "timeout"
+	// InternalCode is any error not returned by this package
+	InternalCode ErrorCode = "internal"
+)
+
+// coder is an error with a code, which every error of this package is.
+type coder interface {
+	error
+	Code() ErrorCode
+}
+
+// newErrors builds each error with a code that carries no details,
+// so clients can rebuild it from the code alone.
+var newErrors = []func() error{
+	func() error { return &GameNotFoundErr{} },
+	func() error { return &GameExistsErr{} },
+	func() error { return &NotYourTurnErr{} },
+	func() error { return &TooManyPlayersErr{} },
+	func() error { return &IllegalMoveErr{} },
+	func() error { return &InvalidTokenErr{} },
+	func() error { return &GameOverErr{} },
+	func() error { return &UnknownMessageErr{} },
+	func() error { return &UnknownDifficultyErr{} },
+	func() error { return &InvalidBoardErr{} },
+	func() error { return &NoTakebackErr{} },
+	func() error { return &NothingToTakeBackErr{} },
+	func() error { return &InvalidSeriesErr{} },
+	func() error { return &GameNotOverErr{} },
+	func() error { return &SeriesOverErr{} },
+	func() error { return &InvalidTimeControlErr{} },
+	func() error { return &InvalidFilterErr{} },
+	func() error { return &MatchAIErr{} },
+	func() error { return &InvalidNameErr{} },
+	func() error { return &ReservedGameIDErr{} },
+	func() error { return &AccountExistsErr{} },
+	func() error { return &AccountNotFoundErr{} },
+	func() error { return &SeatInPlayErr{} },
+	func() error { return &SamePlayerErr{} },
+	func() error { return &InvalidSymbolErr{} },
+	func() error { return &OffBoardErr{} },
+	func() error { return &GameNotStartedErr{} },
+	func() error { return &TooManyGamesErr{} },
+	func() error { return &ShuttingDownErr{} },
+	func() error { return &UnknownStrategyErr{} },
+	func() error { return &TooFewStrategiesErr{} },
+	func() error { return &NoRoundsErr{} },
+	func() error { return &DuplicateStrategyErr{} },
+	func() error { return &InvalidPositionErr{} },
+	func() error { return &TooLargeToSolveErr{} },
+	func() error { return &TimeoutErr{} },
+}
+
+// errorCodes maps each code to the constructor of its error.
+var errorCodes = map[ErrorCode]func() error{}
+
+func init() {
+	for _, newErr := range newErrors {
+		code := newErr().(coder).Code()
+		if _, ok := errorCodes[code]; ok {
+			panic(fmt.Sprintf("error code %s is used twice", code))
+		}
+		errorCodes[code] = newErr
+	}
+}
+
+// ErrorResponse is the body of a failed API request.
+type ErrorResponse struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"error"`
+}
+
+// CodeOf returns the code of err, or of the first error it wraps
+// that has one.
+func CodeOf(err error) ErrorCode {
+
+	for ; err != nil; err = errors.Unwrap(err) {
+		if coded, ok := err.(coder); ok {
+			return coded.Code()
+		}
+	}
+
+	return InternalCode
+}
+
+// ErrorFromCode rebuilds the error an API response stands for, so
+// clients can check for it with errors.As. Unknown codes keep the
+// message only.
+func ErrorFromCode(code ErrorCode, message string) error {
+
+	if code == BadRequestCode {
+		return &BadRequestErr{Reason: message}
+	}
+
+	newErr, ok := errorCodes[code]
+	if !ok {
+		return errors.New(message)
+	}
+
+	return newErr()
+}
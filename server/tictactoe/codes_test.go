@@ -0,0 +1,33 @@
+package tictactoe
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCodes(t *testing.T) {
+
+	for code, newErr := range errorCodes {
+		known := newErr()
+		require.Equal(t, code, CodeOf(known))
+
+		err := ErrorFromCode(code, known.Error())
+		require.Equal(t, reflect.TypeOf(known), reflect.TypeOf(err), code)
+		require.Equal(t, known.Error(), err.Error())
+	}
+
+	wrapped := fmt.Errorf("moving: %w", &NotYourTurnErr{})
+	require.Equal(t, NotYourTurnCode, CodeOf(wrapped))
+
+	require.Equal(t, BadRequestCode, CodeOf(&BadRequestErr{Reason: "Could not parse width"}))
+	var bad *BadRequestErr
+	require.True(t, errors.As(ErrorFromCode(BadRequestCode, "Could not parse width"), &bad))
+	require.Equal(t, "Could not parse width", bad.Reason)
+
+	require.Equal(t, InternalCode, CodeOf(errors.New("disk full")))
+	require.EqualError(t, ErrorFromCode("from_the_future", "Something new"), "Something new")
+}
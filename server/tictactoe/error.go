@@ -7,6 +7,10 @@ func (g *GameNotFoundErr) Error() string {
 	return "Game not found"
 }
 
+func (g *GameNotFoundErr) Code() ErrorCode {
+	return GameNotFoundCode
+}
+
 type GameExistsErr struct {
 }
 
@@ -14,6 +18,10 @@ func (g *GameExistsErr) Error() string {
 	return "Game with that name already exists"
 }
 
+func (g *GameExistsErr) Code() ErrorCode {
+	return GameExistsCode
+}
+
 type NotYourTurnErr struct {
 }
 
@@ -21,6 +29,10 @@ func (g *NotYourTurnErr) Error() string {
 	return "Not your turn"
 }
 
+func (g *NotYourTurnErr) Code() ErrorCode {
+	return NotYourTurnCode
+}
+
 type TooManyPlayersErr struct {
 }
 
@@ -28,9 +40,372 @@ func (g *TooManyPlayersErr) Error() string {
 	return "Too many players in this game to join"
 }
 
+func (g *TooManyPlayersErr) Code() ErrorCode {
+	return TooManyPlayersCode
+}
+
 type IllegalMoveErr struct {
 }
 
 func (g *IllegalMoveErr) Error() string {
-	return "Illegal move."
+	return "That cell is already taken"
+}
+
+func (g *IllegalMoveErr) Code() ErrorCode {
+	return IllegalMoveCode
+}
+
+type InvalidTokenErr struct {
+}
+
+func (g *InvalidTokenErr) Error() string {
+	return "Invalid player token"
+}
+
+func (g *InvalidTokenErr) Code() ErrorCode {
+	return InvalidTokenCode
+}
+
+type GameOverErr struct {
+}
+
+func (g *GameOverErr) Error() string {
+	return "Game is already over"
+}
+
+func (g *GameOverErr) Code() ErrorCode {
+	return GameOverCode
+}
+
+type UnknownMessageErr struct {
+}
+
+func (g *UnknownMessageErr) Error() string {
+	return "Unknown message type"
+}
+
+func (g *UnknownMessageErr) Code() ErrorCode {
+	return UnknownMessageCode
+}
+
+type UnknownDifficultyErr struct {
+}
+
+func (g *UnknownDifficultyErr) Error() string {
+	return "Unknown AI difficulty"
+}
+
+func (g *UnknownDifficultyErr) Code() ErrorCode {
+	return UnknownDifficultyCode
+}
+
+type InvalidBoardErr struct {
+}
+
+func (g *InvalidBoardErr) Error() string {
+	return "Invalid board size or win length"
+}
+
+func (g *InvalidBoardErr) Code() ErrorCode {
+	return InvalidBoardCode
+}
+
+type NoTakebackErr struct {
+}
+
+func (g *NoTakebackErr) Error() string {
+	return "No takeback has been requested"
+}
+
+func (g *NoTakebackErr) Code() ErrorCode {
+	return NoTakebackCode
+}
+
+type NothingToTakeBackErr struct {
+}
+
+func (g *NothingToTakeBackErr) Error() string {
+	return "There is no move to take back"
+}
+
+func (g *NothingToTakeBackErr) Code() ErrorCode {
+	return NothingToTakeBackCode
+}
+
+type InvalidSeriesErr struct {
+}
+
+func (g *InvalidSeriesErr) Error() string {
+	return "Series length cannot be negative"
+}
+
+func (g *InvalidSeriesErr) Code() ErrorCode {
+	return InvalidSeriesCode
+}
+
+type GameNotOverErr struct {
+}
+
+func (g *GameNotOverErr) Error() string {
+	return "Game is not over yet"
+}
+
+func (g *GameNotOverErr) Code() ErrorCode {
+	return GameNotOverCode
+}
+
+type SeriesOverErr struct {
+}
+
+func (g *SeriesOverErr) Error() string {
+	return "Series is already decided"
+}
+
+func (g *SeriesOverErr) Code() ErrorCode {
+	return SeriesOverCode
+}
+
+type InvalidTimeControlErr struct {
+}
+
+func (g *InvalidTimeControlErr) Error() string {
+	return "Time control is not valid"
+}
+
+func (g *InvalidTimeControlErr) Code() ErrorCode {
+	return InvalidTimeControlCode
+}
+
+type InvalidFilterErr struct {
+}
+
+func (g *InvalidFilterErr) Error() string {
+	return "Game filter is not valid"
+}
+
+func (g *InvalidFilterErr) Code() ErrorCode {
+	return InvalidFilterCode
+}
+
+type MatchAIErr struct {
+}
+
+func (g *MatchAIErr) Error() string {
+	return "Games against the computer are not matched"
+}
+
+func (g *MatchAIErr) Code() ErrorCode {
+	return MatchAICode
+}
+
+type InvalidNameErr struct {
+}
+
+func (g *InvalidNameErr) Error() string {
+	return "Player names must be 1 to 32 letters, digits, - or _"
+}
+
+func (g *InvalidNameErr) Code() ErrorCode {
+	return InvalidNameCode
+}
+
+type ReservedGameIDErr struct {
+}
+
+func (g *ReservedGameIDErr) Error() string {
+	return "That game name is reserved"
+}
+
+func (g *ReservedGameIDErr) Code() ErrorCode {
+	return ReservedGameIDCode
+}
+
+type AccountExistsErr struct {
+}
+
+func (g *AccountExistsErr) Error() string {
+	return "Player name is already taken"
+}
+
+func (g *AccountExistsErr) Code() ErrorCode {
+	return AccountExistsCode
+}
+
+type AccountNotFoundErr struct {
+}
+
+func (g *AccountNotFoundErr) Error() string {
+	return "Player not found"
+}
+
+func (g *AccountNotFoundErr) Code() ErrorCode {
+	return AccountNotFoundCode
+}
+
+type SeatInPlayErr struct {
+}
+
+func (g *SeatInPlayErr) Error() string {
+	return "Seats can only be claimed before they have moved"
+}
+
+func (g *SeatInPlayErr) Code() ErrorCode {
+	return SeatInPlayCode
+}
+
+type SamePlayerErr struct {
+}
+
+func (g *SamePlayerErr) Error() string {
+	return "A player cannot take both seats"
+}
+
+func (g *SamePlayerErr) Code() ErrorCode {
+	return SamePlayerCode
+}
+
+// BadRequestErr is a request that could not be understood, such as
+// a number that does not parse.
+type BadRequestErr struct {
+	Reason string
+}
+
+func (g *BadRequestErr) Error() string {
+	return g.Reason
+}
+
+func (g *BadRequestErr) Code() ErrorCode {
+	return BadRequestCode
+}
+
+type TimeoutErr struct {
+}
+
+func (g *TimeoutErr) Error() string {
+	return "Timed out waiting, try again"
+}
+
+func (g *TimeoutErr) Code() ErrorCode {
+	return TimeoutCode
+}
+
+type InvalidSymbolErr struct {
+}
+
+func (g *InvalidSymbolErr) Error() string {
+	return "Symbol must be X or O"
+}
+
+func (g *InvalidSymbolErr) Code() ErrorCode {
+	return InvalidSymbolCode
+}
+
+type OffBoardErr struct {
+}
+
+func (g *OffBoardErr) Error() string {
+	return "Move is off the board"
+}
+
+func (g *OffBoardErr) Code() ErrorCode {
+	return OffBoardCode
+}
+
+type GameNotStartedErr struct {
+}
+
+func (g *GameNotStartedErr) Error() string {
+	return "Waiting for an opponent to join"
+}
+
+func (g *GameNotStartedErr) Code() ErrorCode {
+	return GameNotStartedCode
+}
+
+type TooManyGamesErr struct {
+}
+
+func (g *TooManyGamesErr) Error() string {
+	return "The server is full, try again later"
+}
+
+func (g *TooManyGamesErr) Code() ErrorCode {
+	return TooManyGamesCode
+}
+
+type ShuttingDownErr struct {
+}
+
+func (g *ShuttingDownErr) Error() string {
+	return "The server is shutting down"
+}
+
+func (g *ShuttingDownErr) Code() ErrorCode {
+	return ShuttingDownCode
+}
+
+type UnknownStrategyErr struct {
+}
+
+func (g *UnknownStrategyErr) Error() string {
+	return "No strategy with that name"
+}
+
+func (g *UnknownStrategyErr) Code() ErrorCode {
+	return UnknownStrategyCode
+}
+
+type TooFewStrategiesErr struct {
+}
+
+func (g *TooFewStrategiesErr) Error() string {
+	return "A tournament needs at least two strategies"
+}
+
+func (g *TooFewStrategiesErr) Code() ErrorCode {
+	return TooFewStrategiesCode
+}
+
+type NoRoundsErr struct {
+}
+
+func (g *NoRoundsErr) Error() string {
+	return "A tournament needs at least one round"
+}
+
+func (g *NoRoundsErr) Code() ErrorCode {
+	return NoRoundsCode
+}
+
+type DuplicateStrategyErr struct {
+}
+
+func (g *DuplicateStrategyErr) Error() string {
+	return "Strategy entered twice"
+}
+
+func (g *DuplicateStrategyErr) Code() ErrorCode {
+	return DuplicateStrategyCode
+}
+
+type InvalidPositionErr struct {
+}
+
+func (g *InvalidPositionErr) Error() string {
+	return "That position cannot come up in a game"
+}
+
+func (g *InvalidPositionErr) Code() ErrorCode {
+	return InvalidPositionCode
+}
+
+type TooLargeToSolveErr struct {
+}
+
+func (g *TooLargeToSolveErr) Error() string {
+	return "The position is too large to solve"
+}
+
+func (g *TooLargeToSolveErr) Code() ErrorCode {
+	return TooLargeToSolveCode
 }
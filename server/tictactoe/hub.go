@@ -0,0 +1,230 @@
+package tictactoe
+
+import (
+	"context"
+	"sync"
+)
+
+// OverflowPolicy decides what happens to a subscriber whose
+// buffer is full when a new state is published.
+type OverflowPolicy int
+
+const (
+	// DropOldest discards the oldest buffered state to make room.
+	DropOldest OverflowPolicy = 0
+	// Disconnect closes the subscription.
+	Disconnect OverflowPolicy = 1
+)
+
+// DefaultBuffer is the subscription buffer size used when none is given.
+const DefaultBuffer = 8
+
+// ReplayBuffer is how many of the latest states a hub keeps for
+// subscribers resuming from an earlier sequence number.
+const ReplayBuffer = 64
+
+// Subscription receives every state published for a game. The
+// events channel is closed once the subscription is cancelled, the
+// subscriber is disconnected for falling behind or the game ends.
+type Subscription struct {
+	events chan GameState
+	done   chan struct{}
+	policy OverflowPolicy
+	hub    *hub
+	// spectator subscriptions are counted on the game's state
+	spectator bool
+}
+
+// Events returns the channel states are delivered on.
+func (s *Subscription) Events() <-chan GameState {
+	return s.events
+}
+
+// Unsubscribe stops delivery and closes the events channel. It is
+// safe to call more than once.
+func (s *Subscription) Unsubscribe() {
+	s.hub.remove(s)
+}
+
+// hub fans published states out to subscribers without ever
+// blocking the publisher. Every published state is stamped with
+// the next sequence number.
+type hub struct {
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	seq    uint64
+	replay []GameState
+	closed bool
+}
+
+func newHub() *hub {
+	return &hub{
+		subs: map[*Subscription]struct{}{},
+	}
+}
+
+// subscribe registers a subscriber that is removed when ctx is done.
+func (h *hub) subscribe(ctx context.Context, buffer int, policy OverflowPolicy) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.add(ctx, nil, buffer, policy)
+}
+
+// subscribeAfter is like subscribe but first delivers the retained
+// states with a sequence number greater than seq. When some of them
+// are no longer retained the snapshot, the latest state of the game,
+// is delivered in their place.
+func (h *hub) subscribeAfter(ctx context.Context, seq uint64, snapshot GameState, buffer int, policy OverflowPolicy) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if seq < h.seq && (len(h.replay) == 0 || h.replay[0].Seq > seq+1) {
+		return h.add(ctx, []GameState{snapshot}, buffer, policy)
+	}
+
+	missed := []GameState{}
+	for _, state := range h.replay {
+		if state.Seq > seq {
+			missed = append(missed, state)
+		}
+	}
+
+	return h.add(ctx, missed, buffer, policy)
+}
+
+// watch is like subscribe for a spectator, who is counted until the
+// subscription is gone.
+func (h *hub) watch(ctx context.Context, buffer int, policy OverflowPolicy) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub, err := h.add(ctx, nil, buffer, policy)
+	if err != nil {
+		return nil, err
+	}
+	sub.spectator = true
+	return sub, nil
+}
+
+// spectators counts the spectator subscriptions.
+func (h *hub) spectators() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n := 0
+	for sub := range h.subs {
+		if sub.spectator {
+			n++
+		}
+	}
+	return n
+}
+
+// add must be called with the lock held.
+func (h *hub) add(ctx context.Context, missed []GameState, buffer int, policy OverflowPolicy) (*Subscription, error) {
+
+	if h.closed {
+		return nil, &GameNotFoundErr{}
+	}
+
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+
+	sub := &Subscription{
+		// room for the missed states on top of the buffer
+		events: make(chan GameState, buffer+len(missed)),
+		done:   make(chan struct{}),
+		policy: policy,
+		hub:    h,
+	}
+
+	for _, state := range missed {
+		sub.events <- state
+	}
+	h.subs[sub] = struct{}{}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			sub.Unsubscribe()
+		case <-sub.done:
+		}
+	}()
+
+	return sub, nil
+}
+
+// last returns the sequence number of the latest published state.
+func (h *hub) last() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.seq
+}
+
+// publish stamps the state with the next sequence number and
+// delivers it to every subscriber, applying each subscriber's
+// overflow policy when its buffer is full.
+func (h *hub) publish(state GameState) GameState {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	state.Seq = h.seq
+
+	h.replay = append(h.replay, state)
+	if len(h.replay) > ReplayBuffer {
+		h.replay = h.replay[len(h.replay)-ReplayBuffer:]
+	}
+
+	for sub := range h.subs {
+		select {
+		case sub.events <- state:
+			continue
+		default:
+		}
+
+		if sub.policy == Disconnect {
+			h.drop(sub)
+			continue
+		}
+
+		// only the publisher sends and it holds the lock, so once
+		// the oldest state is gone there is room for the new one
+		select {
+		case <-sub.events:
+		default:
+		}
+		sub.events <- state
+	}
+
+	return state
+}
+
+// close ends every subscription and rejects new ones.
+func (h *hub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subs {
+		h.drop(sub)
+	}
+}
+
+func (h *hub) remove(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.drop(sub)
+}
+
+// drop must be called with the lock held.
+func (h *hub) drop(sub *Subscription) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	close(sub.events)
+	close(sub.done)
+}
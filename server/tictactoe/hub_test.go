@@ -0,0 +1,199 @@
+package tictactoe
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHubDropOldest(t *testing.T) {
+
+	h := newHub()
+
+	sub, err := h.subscribe(context.Background(), 2, DropOldest)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		h.publish(GameState{Event: EventType(i)})
+	}
+
+	require.Equal(t, EventType(3), (<-sub.Events()).Event)
+	require.Equal(t, EventType(4), (<-sub.Events()).Event)
+}
+
+func TestHubDisconnect(t *testing.T) {
+
+	h := newHub()
+
+	sub, err := h.subscribe(context.Background(), 1, Disconnect)
+	require.NoError(t, err)
+
+	h.publish(GameState{Event: MoveEvent})
+	h.publish(GameState{Event: WinEvent})
+
+	state, ok := <-sub.Events()
+	require.True(t, ok)
+	require.Equal(t, MoveEvent, state.Event)
+
+	_, ok = <-sub.Events()
+	require.False(t, ok)
+}
+
+func TestHubContextCancel(t *testing.T) {
+
+	h := newHub()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, err := h.subscribe(ctx, 0, DropOldest)
+	require.NoError(t, err)
+
+	cancel()
+
+	select {
+	case _, ok := <-sub.Events():
+		require.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("subscription was not closed on cancel")
+	}
+
+	// publishing to a cancelled subscriber must not block or panic
+	h.publish(GameState{Event: MoveEvent})
+	sub.Unsubscribe()
+}
+
+func TestEndGameClosesSubscriptions(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	x, err := ttt.CreateGame("closing", X, GameOptions{})
+	require.NoError(t, err)
+
+	sub, err := ttt.Subscribe(context.Background(), "closing", 0, DropOldest)
+	require.NoError(t, err)
+
+	require.NoError(t, ttt.EndGame("closing", x.Token))
+
+	state, ok := <-sub.Events()
+	require.True(t, ok)
+	require.Equal(t, EndedEvent, state.Event)
+
+	_, ok = <-sub.Events()
+	require.False(t, ok)
+
+	_, err = ttt.Subscribe(context.Background(), "closing", 0, DropOldest)
+	require.IsType(t, &GameNotFoundErr{}, err)
+}
+
+func TestHubSubscribeAfter(t *testing.T) {
+
+	h := newHub()
+
+	for i := 0; i < 3; i++ {
+		state := h.publish(GameState{Event: MoveEvent})
+		require.Equal(t, uint64(i+1), state.Seq)
+	}
+
+	sub, err := h.subscribeAfter(context.Background(), 1, GameState{}, 1, Disconnect)
+	require.NoError(t, err)
+
+	h.publish(GameState{Event: WinEvent})
+
+	for _, seq := range []uint64{2, 3, 4} {
+		state := <-sub.Events()
+		require.Equal(t, seq, state.Seq)
+	}
+
+	// states older than the replay buffer are gone
+	for i := 0; i < ReplayBuffer+10; i++ {
+		h.publish(GameState{Event: MoveEvent})
+	}
+
+	oldest := h.last() - ReplayBuffer + 1
+	sub, err = h.subscribeAfter(context.Background(), oldest-1, GameState{}, 1, DropOldest)
+	require.NoError(t, err)
+	require.Len(t, sub.Events(), ReplayBuffer)
+	require.Equal(t, oldest, (<-sub.Events()).Seq)
+
+	// a subscriber that missed them gets the snapshot alone
+	snapshot := GameState{Event: NoEvent, Seq: h.last()}
+	sub, err = h.subscribeAfter(context.Background(), oldest-2, snapshot, 1, DropOldest)
+	require.NoError(t, err)
+	require.Len(t, sub.Events(), 1)
+	require.Equal(t, snapshot, <-sub.Events())
+
+	// as does one resuming a game restored without its states
+	restored := newHub()
+	restored.seq = 10
+	sub, err = restored.subscribeAfter(context.Background(), 5, snapshot, 1, DropOldest)
+	require.NoError(t, err)
+	require.Equal(t, snapshot, <-sub.Events())
+
+	// and nothing is missed once up to date
+	sub, err = restored.subscribeAfter(context.Background(), 10, snapshot, 1, DropOldest)
+	require.NoError(t, err)
+	require.Empty(t, sub.Events())
+}
+
+func TestSubscribeAfterMissedReplay(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	x, err := ttt.CreateGame("chatty", X, GameOptions{})
+	require.NoError(t, err)
+	_, err = ttt.JoinGame("chatty")
+	require.NoError(t, err)
+	_, err = ttt.Move("chatty", X, x.Token, 4)
+	require.NoError(t, err)
+
+	for i := 0; i < ReplayBuffer; i++ {
+		require.NoError(t, ttt.Chat("chatty", X, x.Token, "hello"))
+	}
+
+	// the move is no longer retained, the current state stands in
+	sub, err := ttt.SubscribeAfter(context.Background(), "chatty", 1, 0, Disconnect)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	require.Len(t, sub.Events(), 1)
+	state := <-sub.Events()
+	require.Equal(t, NoEvent, state.Event)
+	require.Equal(t, X, state.Board[4])
+	current, err := ttt.GetGame("chatty")
+	require.NoError(t, err)
+	require.Equal(t, current.Seq, state.Seq)
+}
+
+func TestWatch(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	x, err := ttt.CreateGame("watched", X, GameOptions{})
+	require.NoError(t, err)
+	_, err = ttt.JoinGame("watched")
+	require.NoError(t, err)
+
+	// players following the game are not spectators
+	_, err = ttt.Subscribe(context.Background(), "watched", 0, DropOldest)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first, err := ttt.Watch(ctx, "watched", 0, DropOldest)
+	require.NoError(t, err)
+	second, err := ttt.Watch(context.Background(), "watched", 0, DropOldest)
+	require.NoError(t, err)
+
+	state, err := ttt.Move("watched", X, x.Token, 4)
+	require.NoError(t, err)
+	require.Equal(t, 2, state.Spectators)
+	require.Equal(t, MoveEvent, (<-second.Events()).Event)
+
+	second.Unsubscribe()
+	cancel()
+	<-first.done
+
+	state, err = ttt.GetGame("watched")
+	require.NoError(t, err)
+	require.Equal(t, 0, state.Spectators)
+}
@@ -0,0 +1,102 @@
+package tictactoe
+
+import "fmt"
+
+// Status is where a game is in its life. A game waits for its second
+// player, is played until it is won, drawn or resigned and can then
+// be played again with a rematch. A game removed before it finished
+// is abandoned. See CanBecome for every move between statuses.
+type Status string
+
+const (
+	// Waiting games have a seat open
+	Waiting Status = "waiting"
+	Playing Status = "playing"
+	// WonStatus games were won on the board or on time, named apart
+	// from the Won result
+	WonStatus   Status = "won"
+	DrawnStatus Status = "drawn"
+	Resigned    Status = "resigned"
+	// Abandoned games were ended before they had a result
+	Abandoned Status = "abandoned"
+	// Finished filters for games of any status that is over
+	Finished Status = "finished"
+	// Open filters for games with a seat open, the same as Waiting
+	Open Status = "open"
+)
+
+// transitions lists the statuses each status can move to.
+var transitions = map[Status][]Status{
+	Waiting:     {Playing, Abandoned},
+	Playing:     {WonStatus, DrawnStatus, Resigned, Abandoned},
+	WonStatus:   {Playing},
+	DrawnStatus: {Playing},
+	Resigned:    {Playing},
+}
+
+// CanBecome reports whether a game can move from s to the status.
+func (s Status) CanBecome(to Status) bool {
+	for _, next := range transitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Over reports whether a game of the status can no longer be played
+// on, until a rematch.
+func (s Status) Over() bool {
+	switch s {
+	case WonStatus, DrawnStatus, Resigned, Abandoned:
+		return true
+	}
+	return false
+}
+
+// become moves the game to the status, starts or stops the clock to
+// match and publishes the state with event. Callers guard the move:
+// checkPlaying before a game is won, drawn or resigned, Over before a
+// rematch or abandoning it, and bothSeated before it starts. A move
+// CanBecome refuses is a bug in one of them and panics. The game lock
+// must be held.
+func (g *game) become(to Status, event EventType) *GameState {
+
+	if !g.status.CanBecome(to) {
+		panic(fmt.Sprintf("tictactoe: game %s cannot go from %s to %s", g.id, g.status, to))
+	}
+
+	g.status = to
+	g.startClock()
+
+	return g.publish(event)
+}
+
+// checkPlaying makes sure the game is in progress, the only status
+// moves, resignations and takebacks are legal in. The game lock must
+// be held.
+func (g *game) checkPlaying() error {
+	switch g.status {
+	case Playing:
+		return nil
+	case Waiting:
+		return &GameNotStartedErr{}
+	case Abandoned:
+		return &GameNotFoundErr{}
+	}
+	return &GameOverErr{}
+}
+
+// statusOf works out the status of a game saved before statuses
+// were recorded. Resignations cannot be told apart from wins.
+func statusOf(record *GameRecord) Status {
+	switch {
+	case record.Result == Won:
+		return WonStatus
+	case record.Result == Drawn:
+		return DrawnStatus
+	case len(record.Tokens) == 2:
+		return Playing
+	}
+	return Waiting
+}
@@ -0,0 +1,223 @@
+package tictactoe
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanBecome(t *testing.T) {
+
+	for _, tc := range []struct {
+		from, to Status
+		legal    bool
+	}{
+		{Waiting, Playing, true},
+		{Waiting, Abandoned, true},
+		{Waiting, WonStatus, false},
+		{Playing, WonStatus, true},
+		{Playing, DrawnStatus, true},
+		{Playing, Resigned, true},
+		{Playing, Abandoned, true},
+		{Playing, Waiting, false},
+		{WonStatus, Playing, true},
+		{DrawnStatus, Playing, true},
+		{Resigned, Playing, true},
+		{Resigned, Abandoned, false},
+		{Abandoned, Playing, false},
+	} {
+		require.Equal(t, tc.legal, tc.from.CanBecome(tc.to), "%s to %s", tc.from, tc.to)
+	}
+}
+
+func TestBecomeRefusesIllegalMoves(t *testing.T) {
+
+	tt := NewTicTacToe().(*ttt)
+
+	_, err := tt.CreateGame("life", X, GameOptions{})
+	require.NoError(t, err)
+	game, err := tt.game("life")
+	require.NoError(t, err)
+
+	game.mu.Lock()
+	defer game.mu.Unlock()
+
+	// a waiting game cannot be won
+	require.Panics(t, func() {
+		game.become(WonStatus, NoEvent)
+	})
+	require.Equal(t, Waiting, game.status)
+}
+
+func TestLifecycle(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	x, err := ttt.CreateGame("life", X, GameOptions{})
+	require.NoError(t, err)
+	require.Equal(t, Waiting, x.State.Status)
+
+	sub, err := ttt.Subscribe(context.Background(), "life", 0, DropOldest)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	// nothing can be played before the game starts
+	_, err = ttt.Resign("life", X, x.Token)
+	require.IsType(t, &GameNotStartedErr{}, err)
+	_, err = ttt.RequestTakeback("life", X, x.Token)
+	require.IsType(t, &GameNotStartedErr{}, err)
+	_, err = ttt.Rematch("life", X, x.Token)
+	require.IsType(t, &GameNotOverErr{}, err)
+
+	o, err := ttt.JoinGame("life")
+	require.NoError(t, err)
+	require.Equal(t, Playing, o.State.Status)
+
+	state := <-sub.Events()
+	require.Equal(t, JoinEvent, state.Event)
+	require.Equal(t, Playing, state.Status)
+
+	_, err = ttt.Move("life", X, x.Token, 4)
+	require.NoError(t, err)
+	require.Equal(t, MoveEvent, (<-sub.Events()).Event)
+
+	resigned, err := ttt.Resign("life", O, o.Token)
+	require.NoError(t, err)
+	require.Equal(t, Resigned, resigned.Status)
+	require.Equal(t, Won, resigned.Result)
+	require.Equal(t, X, resigned.Winner)
+	require.Equal(t, Resigned, (<-sub.Events()).Status)
+
+	_, err = ttt.Resign("life", X, x.Token)
+	require.IsType(t, &GameOverErr{}, err)
+	_, err = ttt.RequestTakeback("life", X, x.Token)
+	require.IsType(t, &GameOverErr{}, err)
+
+	// a rematch plays the game again
+	_, err = ttt.Rematch("life", X, x.Token)
+	require.NoError(t, err)
+	<-sub.Events()
+	state2, err := ttt.Rematch("life", O, o.Token)
+	require.NoError(t, err)
+	require.Equal(t, Playing, state2.Status)
+	require.Equal(t, RematchEvent, (<-sub.Events()).Event)
+
+	// ending a game before it has a result abandons it
+	require.NoError(t, ttt.EndGame("life", x.Token))
+	state = <-sub.Events()
+	require.Equal(t, EndedEvent, state.Event)
+	require.Equal(t, Abandoned, state.Status)
+}
+
+func TestLifecycleTransitions(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	x, err := ttt.CreateGame("legal", X, GameOptions{})
+	require.NoError(t, err)
+
+	sub, err := ttt.Subscribe(context.Background(), "legal", 32, DropOldest)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	// every way the status changes through the API
+	o, err := ttt.JoinGame("legal")
+	require.NoError(t, err)
+	_, err = ttt.Resign("legal", O, o.Token)
+	require.NoError(t, err)
+	_, err = ttt.Rematch("legal", X, x.Token)
+	require.NoError(t, err)
+	_, err = ttt.Rematch("legal", O, o.Token)
+	require.NoError(t, err)
+	seats := map[Symbol]string{X: x.Token, O: o.Token}
+	symbol := O
+	for _, index := range []int{0, 3, 1, 4, 2} {
+		_, err = ttt.Move("legal", symbol, seats[symbol], index)
+		require.NoError(t, err)
+		symbol = opponent(symbol)
+	}
+	_, err = ttt.Rematch("legal", X, x.Token)
+	require.NoError(t, err)
+	_, err = ttt.Rematch("legal", O, o.Token)
+	require.NoError(t, err)
+	require.NoError(t, ttt.EndGame("legal", x.Token))
+
+	from := Waiting
+	changes := 0
+	for state := range sub.Events() {
+		if state.Status != from {
+			require.True(t, from.CanBecome(state.Status), "%s to %s", from, state.Status)
+			from = state.Status
+			changes++
+		}
+	}
+	require.Equal(t, Abandoned, from)
+	require.Equal(t, 6, changes)
+}
+
+func TestLifecycleEnds(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	// a finished game keeps its status when it is ended
+	x, err := ttt.CreateGame("drawn", X, GameOptions{})
+	require.NoError(t, err)
+	o, err := ttt.JoinGame("drawn")
+	require.NoError(t, err)
+
+	seats := []*JoinResponse{x, o}
+	var state *GameState
+	for i, index := range []int{0, 1, 2, 4, 3, 5, 7, 6, 8} {
+		seat := seats[i%2]
+		state, err = ttt.Move("drawn", seat.Symbol, seat.Token, index)
+		require.NoError(t, err)
+	}
+	require.Equal(t, DrawnStatus, state.Status)
+
+	sub, err := ttt.Subscribe(context.Background(), "drawn", 0, DropOldest)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	require.NoError(t, ttt.EndGame("drawn", o.Token))
+	state2 := <-sub.Events()
+	require.Equal(t, EndedEvent, state2.Event)
+	require.Equal(t, DrawnStatus, state2.Status)
+
+	// a waiting game is abandoned
+	w, err := ttt.CreateGame("waiting", O, GameOptions{})
+	require.NoError(t, err)
+
+	sub, err = ttt.Subscribe(context.Background(), "waiting", 0, DropOldest)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	require.NoError(t, ttt.EndGame("waiting", w.Token))
+	require.Equal(t, Abandoned, (<-sub.Events()).Status)
+}
+
+func TestLifecycleRestore(t *testing.T) {
+
+	store := NewMemoryStore()
+
+	ttt, err := NewTicTacToeFromStore(store)
+	require.NoError(t, err)
+
+	x, err := ttt.CreateGame("saved", X, GameOptions{AI: Easy})
+	require.NoError(t, err)
+	_, err = ttt.Resign("saved", X, x.Token)
+	require.NoError(t, err)
+
+	ttt, err = NewTicTacToeFromStore(store)
+	require.NoError(t, err)
+
+	state, err := ttt.GetGame("saved")
+	require.NoError(t, err)
+	require.Equal(t, Resigned, state.Status)
+
+	// records from before statuses were saved
+	require.Equal(t, WonStatus, statusOf(&GameRecord{Result: Won}))
+	require.Equal(t, DrawnStatus, statusOf(&GameRecord{Result: Drawn}))
+	require.Equal(t, Playing, statusOf(&GameRecord{Tokens: map[Symbol]string{X: "x", O: "o"}}))
+	require.Equal(t, Waiting, statusOf(&GameRecord{Tokens: map[Symbol]string{X: "x"}}))
+}
@@ -0,0 +1,146 @@
+package tictactoe
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultListLimit is how many games are listed when no
+	// limit is given.
+	DefaultListLimit = 50
+	// MaxListLimit is the most games listed at once.
+	MaxListLimit = 200
+)
+
+// GameSummary describes a game in the list of games.
+type GameSummary struct {
+	Shape
+	ID     GameID `json:"id"`
+	Status Status `json:"status"`
+	Turn   Symbol `json:"turn"`
+	// Seats are the symbols nobody has taken yet
+	Seats      []Symbol  `json:"seats"`
+	Spectators int       `json:"spectators"`
+	Created    time.Time `json:"created"`
+}
+
+// GameFilter picks a page of the games with the given status, where
+// Finished stands for any status the game is over in. The zero value
+// lists the first DefaultListLimit games of any status.
+type GameFilter struct {
+	Status Status `json:"status"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+}
+
+// GameList is a page of games, oldest first. Total counts every game
+// matching the filter.
+type GameList struct {
+	Games []GameSummary `json:"games"`
+	Total int           `json:"total"`
+}
+
+// FindGames lists the games matching the filter.
+func (t *ttt) FindGames(filter GameFilter) (*GameList, error) {
+
+	if filter.Status == Open {
+		filter.Status = Waiting
+	}
+
+	switch filter.Status {
+	case "", Waiting, Playing, Finished, WonStatus, DrawnStatus, Resigned:
+	default:
+		return nil, &InvalidFilterErr{}
+	}
+
+	if filter.Offset < 0 || filter.Limit < 0 {
+		return nil, &InvalidFilterErr{}
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DefaultListLimit
+	}
+	if filter.Limit > MaxListLimit {
+		filter.Limit = MaxListLimit
+	}
+
+	// each game is locked after the registry is released, so a busy
+	// game holds up the listing but not the registry
+	t.mu.RLock()
+	all := make([]*game, 0, len(t.games))
+	for _, game := range t.games {
+		all = append(all, game)
+	}
+	t.mu.RUnlock()
+
+	games := []GameSummary{}
+	for _, game := range all {
+		game.mu.Lock()
+		ended, summary := game.ended, game.summary()
+		game.mu.Unlock()
+
+		// ended since the registry was read
+		if ended {
+			continue
+		}
+
+		if filter.matches(summary.Status) {
+			games = append(games, summary)
+		}
+	}
+
+	sort.Slice(games, func(i, j int) bool {
+		if !games[i].Created.Equal(games[j].Created) {
+			return games[i].Created.Before(games[j].Created)
+		}
+		return games[i].ID < games[j].ID
+	})
+
+	list := &GameList{
+		Games: []GameSummary{},
+		Total: len(games),
+	}
+
+	if filter.Offset < len(games) {
+		end := filter.Offset + filter.Limit
+		if end > len(games) {
+			end = len(games)
+		}
+		list.Games = games[filter.Offset:end]
+	}
+
+	return list, nil
+}
+
+// matches reports whether a game with the status passes the filter.
+func (f GameFilter) matches(status Status) bool {
+	switch f.Status {
+	case "":
+		return true
+	case Finished:
+		return status.Over()
+	}
+	return f.Status == status
+}
+
+// summary describes the game for the list of games. The game lock
+// must be held.
+func (g *game) summary() GameSummary {
+
+	seats := []Symbol{}
+	for _, symbol := range []Symbol{X, O} {
+		if _, ok := g.tokens[symbol]; !ok {
+			seats = append(seats, symbol)
+		}
+	}
+
+	return GameSummary{
+		Shape:      g.shape,
+		ID:         g.id,
+		Status:     g.status,
+		Turn:       g.turn,
+		Seats:      seats,
+		Spectators: g.hub.spectators(),
+		Created:    g.created,
+	}
+}
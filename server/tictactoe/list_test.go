@@ -0,0 +1,131 @@
+package tictactoe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindGames(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	// a waits for an opponent, b and c are being played and d is over
+	_, err := ttt.CreateGame("a", O, GameOptions{})
+	require.NoError(t, err)
+
+	_, err = ttt.CreateGame("b", X, GameOptions{Shape: Shape{Width: 5, Height: 4, WinLength: 4}})
+	require.NoError(t, err)
+	_, err = ttt.JoinGame("b")
+	require.NoError(t, err)
+
+	_, err = ttt.CreateGame("c", X, GameOptions{AI: Easy})
+	require.NoError(t, err)
+
+	d, err := ttt.CreateGame("d", X, GameOptions{AI: Easy})
+	require.NoError(t, err)
+	_, err = ttt.Resign("d", X, d.Token)
+	require.NoError(t, err)
+
+	list, err := ttt.FindGames(GameFilter{})
+	require.NoError(t, err)
+	require.Equal(t, 4, list.Total)
+
+	ids := []GameID{}
+	for _, game := range list.Games {
+		ids = append(ids, game.ID)
+	}
+	require.Equal(t, []GameID{"a", "b", "c", "d"}, ids)
+
+	a := list.Games[0]
+	require.Equal(t, Waiting, a.Status)
+	require.Equal(t, []Symbol{X}, a.Seats)
+	require.Equal(t, O, a.Turn)
+	require.Equal(t, Classic, a.Shape)
+	require.False(t, a.Created.IsZero())
+
+	b := list.Games[1]
+	require.Equal(t, Playing, b.Status)
+	require.Empty(t, b.Seats)
+	require.Equal(t, 5, b.Width)
+
+	require.Equal(t, Resigned, list.Games[3].Status)
+
+	list, err = ttt.FindGames(GameFilter{Status: Finished})
+	require.NoError(t, err)
+	require.Equal(t, 1, list.Total)
+	require.Equal(t, GameID("d"), list.Games[0].ID)
+
+	list, err = ttt.FindGames(GameFilter{Status: Open})
+	require.NoError(t, err)
+	require.Equal(t, 1, list.Total)
+	require.Equal(t, GameID("a"), list.Games[0].ID)
+
+	list, err = ttt.FindGames(GameFilter{Status: Playing, Offset: 1, Limit: 1})
+	require.NoError(t, err)
+	require.Equal(t, 2, list.Total)
+	require.Len(t, list.Games, 1)
+	require.Equal(t, GameID("c"), list.Games[0].ID)
+
+	list, err = ttt.FindGames(GameFilter{Offset: 10})
+	require.NoError(t, err)
+	require.Equal(t, 4, list.Total)
+	require.Empty(t, list.Games)
+
+	for _, filter := range []GameFilter{
+		{Status: "lost"},
+		{Offset: -1},
+		{Limit: -1},
+	} {
+		_, err = ttt.FindGames(filter)
+		require.IsType(t, &InvalidFilterErr{}, err, "%+v", filter)
+	}
+}
+
+func TestFindGamesBusyGame(t *testing.T) {
+
+	tt := NewTicTacToe().(*ttt)
+
+	_, err := tt.CreateGame("busy", X, GameOptions{})
+	require.NoError(t, err)
+	_, err = tt.CreateGame("other", X, GameOptions{})
+	require.NoError(t, err)
+
+	// a listing waits on the busy game
+	busy, err := tt.game("busy")
+	require.NoError(t, err)
+	busy.mu.Lock()
+
+	listed := make(chan *GameList)
+	go func() {
+		list, _ := tt.FindGames(GameFilter{})
+		listed <- list
+	}()
+
+	// other games can still be created, ended and read meanwhile
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		x, err := tt.CreateGame("new", X, GameOptions{})
+		require.NoError(t, err)
+		require.NoError(t, tt.EndGame("new", x.Token))
+		_, err = tt.GetGame("other")
+		require.NoError(t, err)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the registry was held by the listing")
+	}
+
+	busy.mu.Unlock()
+
+	ids := []GameID{}
+	for _, summary := range (<-listed).Games {
+		ids = append(ids, summary.ID)
+	}
+	require.Contains(t, ids, GameID("busy"))
+	require.Contains(t, ids, GameID("other"))
+}
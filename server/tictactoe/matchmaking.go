@@ -0,0 +1,138 @@
+package tictactoe
+
+import (
+	"context"
+	"log"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// ticket is a player waiting in the matchmaking queue. The match is
+// handed over on a buffered channel so the player who completes it
+// never waits on the one who was queued.
+type ticket struct {
+	match chan matchResult
+}
+
+type matchResult struct {
+	resp *JoinResponse
+	err  error
+}
+
+// Matchmake waits until another player asks for a game with the same
+// options and seats both of them in a new game with a generated id.
+// The player who waited longer plays X and moves first. Leaving ctx
+// gives up the place in the queue, a game matched as either player
+// leaves is abandoned so the other is not left facing an empty seat.
+func (t *ttt) Matchmake(ctx context.Context, opts GameOptions) (*JoinResponse, error) {
+
+	if opts.AI != NoAI {
+		return nil, &MatchAIErr{}
+	}
+
+	opts, err := opts.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	t.queueMu.Lock()
+
+	waiting := t.queue[opts]
+	if len(waiting) == 0 {
+		tk := &ticket{match: make(chan matchResult, 1)}
+		t.queue[opts] = append(waiting, tk)
+		t.queueMu.Unlock()
+
+		return t.wait(ctx, opts, tk)
+	}
+
+	other := waiting[0]
+	if len(waiting) == 1 {
+		delete(t.queue, opts)
+	} else {
+		t.queue[opts] = waiting[1:]
+	}
+
+	t.queueMu.Unlock()
+
+	x, o, err := t.pair(opts)
+	other.match <- matchResult{resp: x, err: err}
+
+	if err == nil && ctx.Err() != nil {
+		t.abandon(o)
+		return nil, ctx.Err()
+	}
+
+	return o, err
+}
+
+// wait blocks until the ticket is matched or ctx is done.
+func (t *ttt) wait(ctx context.Context, opts GameOptions, tk *ticket) (*JoinResponse, error) {
+
+	select {
+	case result := <-tk.match:
+		return t.matched(ctx, result)
+	case <-ctx.Done():
+	}
+
+	t.queueMu.Lock()
+	waiting := t.queue[opts]
+	for i, other := range waiting {
+		if other == tk {
+			t.queue[opts] = append(waiting[:i:i], waiting[i+1:]...)
+			if len(t.queue[opts]) == 0 {
+				delete(t.queue, opts)
+			}
+			t.queueMu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+	t.queueMu.Unlock()
+
+	// a match was being made as ctx ended
+	return t.matched(ctx, <-tk.match)
+}
+
+// matched hands the match to a waiting player, unless the player has
+// left meanwhile and nobody is left to take the seat.
+func (t *ttt) matched(ctx context.Context, result matchResult) (*JoinResponse, error) {
+	if result.err == nil && ctx.Err() != nil {
+		t.abandon(result.resp)
+		return nil, ctx.Err()
+	}
+	return result.resp, result.err
+}
+
+// abandon ends a matched game whose player left before hearing about
+// it, which tells the opponent the game is abandoned.
+func (t *ttt) abandon(seat *JoinResponse) {
+	if err := t.EndGame(seat.State.ID, seat.Token); err != nil {
+		log.Printf("WARN: could not abandon game %s: %v", seat.State.ID, err)
+	}
+}
+
+// pair creates a game for two matched players.
+func (t *ttt) pair(opts GameOptions) (*JoinResponse, *JoinResponse, error) {
+
+	for {
+		id := GameID("match-" + uuid.NewV4().String()[:8])
+
+		x, err := t.CreateGame(id, X, opts)
+		if _, ok := err.(*GameExistsErr); ok {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		o, err := t.JoinGame(id)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// both players start from the game with both seats taken
+		x.State = o.State
+
+		return x, o, nil
+	}
+}
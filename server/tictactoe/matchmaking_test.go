@@ -0,0 +1,97 @@
+package tictactoe
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchmake(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	first := make(chan *JoinResponse)
+	go func() {
+		resp, err := ttt.Matchmake(context.Background(), GameOptions{})
+		require.NoError(t, err)
+		first <- resp
+	}()
+
+	// wait for the first player to be queued
+	require.Eventually(t, func() bool {
+		return queued(ttt, Classic) == 1
+	}, time.Second, time.Millisecond)
+
+	// a different board is not a match
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := ttt.Matchmake(ctx, GameOptions{Shape: Shape{Width: 4, Height: 4, WinLength: 3}})
+	require.Equal(t, context.DeadlineExceeded, err)
+
+	o, err := ttt.Matchmake(context.Background(), GameOptions{Shape: Classic})
+	require.NoError(t, err)
+	x := <-first
+
+	require.Equal(t, X, x.Symbol)
+	require.Equal(t, O, o.Symbol)
+	require.Equal(t, x.State.ID, o.State.ID)
+	require.NotEqual(t, x.Token, o.Token)
+	require.Equal(t, 0, queued(ttt, Classic))
+
+	// the waiting player moves first
+	_, err = ttt.Move(x.State.ID, X, x.Token, 4)
+	require.NoError(t, err)
+}
+
+func TestMatchmakeLeaveQueue(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := ttt.Matchmake(ctx, GameOptions{})
+	require.Equal(t, context.Canceled, err)
+	require.Equal(t, 0, queued(ttt, Classic))
+
+	_, err = ttt.Matchmake(context.Background(), GameOptions{AI: Hard})
+	require.IsType(t, &MatchAIErr{}, err)
+}
+
+func TestMatchmakeLeaveWhileMatched(t *testing.T) {
+
+	tt := NewTicTacToe().(*ttt)
+
+	// the match arrives as the waiting player leaves
+	x, o, err := tt.pair(GameOptions{Shape: Classic})
+	require.NoError(t, err)
+	tk := &ticket{match: make(chan matchResult, 1)}
+	tk.match <- matchResult{resp: x}
+
+	sub, err := tt.Subscribe(context.Background(), o.State.ID, 0, DropOldest)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = tt.wait(ctx, GameOptions{Shape: Classic}, tk)
+	require.Equal(t, context.Canceled, err)
+
+	// the opponent hears the game was abandoned
+	state := <-sub.Events()
+	require.Equal(t, EndedEvent, state.Event)
+	require.Equal(t, Abandoned, state.Status)
+
+	_, err = tt.GetGame(o.State.ID)
+	require.IsType(t, &GameNotFoundErr{}, err)
+}
+
+func queued(t TicTacToe, shape Shape) int {
+	tt := t.(*ttt)
+	tt.queueMu.Lock()
+	defer tt.queueMu.Unlock()
+	return len(tt.queue[GameOptions{Shape: shape}])
+}
@@ -0,0 +1,247 @@
+package tictactoe
+
+import (
+	"math/rand"
+)
+
+// Player picks moves for a computer controlled seat. Any Strategy
+// can be one.
+type Player = Strategy
+
+// Difficulty selects the strength of a computer player.
+type Difficulty string
+
+const (
+	NoAI Difficulty = ""
+	// Easy plays random cells
+	Easy Difficulty = "easy"
+	// Medium wins and blocks when it can
+	Medium Difficulty = "medium"
+	// Hard plays perfectly once at most 9 cells are empty, before
+	// that it looks PerfectDepth plies ahead
+	Hard Difficulty = "hard"
+)
+
+// NewPlayer returns the computer player for a difficulty.
+func NewPlayer(d Difficulty) (Player, error) {
+	switch d {
+	case Easy:
+		return NewRandomPlayer(), nil
+	case Medium:
+		return NewHeuristicPlayer(), nil
+	case Hard:
+		return NewPerfectPlayer(), nil
+	}
+	return nil, &UnknownDifficultyErr{}
+}
+
+// NewRandomPlayer plays a random empty cell.
+func NewRandomPlayer() Player {
+	return &randomPlayer{}
+}
+
+type randomPlayer struct {
+}
+
+func (p *randomPlayer) Move(state GameState, symbol Symbol) int {
+	cells := emptyCells(state.Board)
+	return cells[rand.Intn(len(cells))]
+}
+
+// NewHeuristicPlayer completes its own lines, blocks the opponent's
+// and otherwise plays the cell with the most lines still open to it,
+// which on the classic board is the center, then corners, then edges.
+func NewHeuristicPlayer() Player {
+	return &heuristicPlayer{}
+}
+
+type heuristicPlayer struct {
+}
+
+func (p *heuristicPlayer) Move(state GameState, symbol Symbol) int {
+
+	board := make([]Symbol, len(state.Board))
+	copy(board, state.Board)
+
+	cells := emptyCells(board)
+
+	for _, s := range []Symbol{symbol, opponent(symbol)} {
+		for _, i := range cells {
+			board[i] = s
+			won := state.Shape.IsWonAt(board, s, i)
+			board[i] = Empty
+			if won {
+				return i
+			}
+		}
+	}
+
+	return bestPositional(state.Shape, board, symbol, cells)
+}
+
+// bestPositional returns the cell with the most open lines, the
+// one closest to the center on a tie.
+func bestPositional(shape Shape, board []Symbol, symbol Symbol, cells []int) int {
+	best, bestLines, bestDistance := cells[0], -1, 0
+	for _, i := range cells {
+		lines := shape.openLines(board, symbol, i)
+		distance := shape.centerDistance(i)
+		if lines > bestLines || (lines == bestLines && distance < bestDistance) {
+			best, bestLines, bestDistance = i, lines, distance
+		}
+	}
+	return best
+}
+
+// PerfectDepth bounds how many plies the perfect player looks ahead
+// while more cells are empty than the classic board has, where a
+// full search is out of reach.
+const PerfectDepth = 3
+
+// PerfectWork bounds the cells the perfect player looks at for a
+// move, each position it searches costs a look at every cell of the
+// board. Large boards have too many candidate cells to search even
+// PerfectDepth plies in time, the player plays the best placed cell
+// instead once the search gets this far.
+const PerfectWork = 1 << 22
+
+// NewPerfectPlayer searches the game tree with minimax and alpha-beta
+// pruning. On the classic board it searches to the end, so it never
+// loses and wins as fast as it can. On larger boards the search is
+// limited to PerfectDepth plies around the played cells until at most
+// 9 cells are empty, so it is not perfect there. No search goes past
+// PerfectWork.
+func NewPerfectPlayer() Player {
+	return &perfectPlayer{}
+}
+
+type perfectPlayer struct {
+}
+
+func (p *perfectPlayer) Move(state GameState, symbol Symbol) int {
+
+	shape := state.Shape
+
+	board := make([]Symbol, len(state.Board))
+	copy(board, state.Board)
+
+	cells := emptyCells(board)
+
+	depth := len(cells)
+	if depth > Classic.Cells() {
+		depth = PerfectDepth
+	}
+
+	search := &lookahead{shape: shape}
+	moves := candidates(shape, board, cells)
+
+	// the search can only tell moves apart by outcome, so among
+	// equally good moves play the best placed one
+	bestScore := -scoreBound(board)
+	best := []int{}
+	for _, i := range moves {
+		board[i] = symbol
+		score := -search.negamax(board, opponent(symbol), i, depth-1, -scoreBound(board), scoreBound(board))
+		board[i] = Empty
+
+		if search.work > PerfectWork {
+			return bestPositional(shape, board, symbol, moves)
+		}
+
+		if score > bestScore {
+			bestScore, best = score, []int{i}
+		} else if score == bestScore {
+			best = append(best, i)
+		}
+	}
+
+	return bestPositional(shape, board, symbol, best)
+}
+
+// lookahead is the search for one move of the perfect player, which
+// counts its work.
+type lookahead struct {
+	shape Shape
+	work  int
+}
+
+// negamax scores the board for the symbol about to move, after the
+// opponent played last. Wins score higher the sooner they happen and
+// positions at the depth limit score as a draw. The search stops
+// scoring once it is past PerfectWork.
+func (l *lookahead) negamax(board []Symbol, symbol Symbol, last, depth, alpha, beta int) int {
+
+	if l.work += len(board); l.work > PerfectWork {
+		return 0
+	}
+
+	shape := l.shape
+	if shape.IsWonAt(board, opponent(symbol), last) {
+		return -(len(emptyCells(board)) + 1)
+	}
+
+	cells := emptyCells(board)
+	if len(cells) == 0 || depth == 0 {
+		return 0
+	}
+
+	for _, i := range candidates(shape, board, cells) {
+		board[i] = symbol
+		score := -l.negamax(board, opponent(symbol), i, depth-1, -beta, -alpha)
+		board[i] = Empty
+
+		if score > alpha {
+			alpha = score
+		}
+		if alpha >= beta || l.work > PerfectWork {
+			break
+		}
+	}
+
+	return alpha
+}
+
+// scoreBound is larger than any score negamax can return.
+func scoreBound(board []Symbol) int {
+	return len(board) + 2
+}
+
+// candidates narrows the empty cells worth searching. Small boards
+// are searched in full, on larger ones only cells next to a played
+// cell are considered.
+func candidates(shape Shape, board []Symbol, cells []int) []int {
+
+	if len(board) <= Classic.Cells() || len(cells) == len(board) {
+		return cells
+	}
+
+	near := []int{}
+	for _, i := range cells {
+		col, row := i%shape.Width, i/shape.Width
+	NEIGHBOURS:
+		for dc := -1; dc <= 1; dc++ {
+			for dr := -1; dr <= 1; dr++ {
+				c, r := col+dc, row+dr
+				if c < 0 || c >= shape.Width || r < 0 || r >= shape.Height {
+					continue
+				}
+				if board[r*shape.Width+c] != Empty {
+					near = append(near, i)
+					break NEIGHBOURS
+				}
+			}
+		}
+	}
+
+	return near
+}
+
+func emptyCells(board []Symbol) []int {
+	cells := []int{}
+	for i, s := range board {
+		if s == Empty {
+			cells = append(cells, i)
+		}
+	}
+	return cells
+}
@@ -0,0 +1,210 @@
+package tictactoe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// playOut plays a game between two computer players and
+// returns the winner.
+func playOut(x, o Player) Symbol {
+
+	board := make([]Symbol, Classic.Cells())
+	players := map[Symbol]Player{X: x, O: o}
+
+	for turn := X; !isFull(board); turn = opponent(turn) {
+		index := players[turn].Move(GameState{Shape: Classic, Board: board}, turn)
+		board[index] = turn
+		if Classic.IsWonAt(board, turn, index) {
+			return turn
+		}
+	}
+
+	return Empty
+}
+
+func TestPerfectPlayer(t *testing.T) {
+
+	perfect := NewPerfectPlayer()
+
+	require.Equal(t, Empty, playOut(perfect, perfect))
+
+	for i := 0; i < 50; i++ {
+		require.NotEqual(t, O, playOut(perfect, NewRandomPlayer()))
+		require.NotEqual(t, X, playOut(NewRandomPlayer(), perfect))
+		require.NotEqual(t, X, playOut(NewHeuristicPlayer(), perfect))
+	}
+
+	// X can win right away
+	board := []Symbol{X, X, "", O, O, "", "", "", ""}
+	require.Equal(t, 2, perfect.Move(GameState{Shape: Classic, Board: board}, X))
+}
+
+func TestHeuristicPlayer(t *testing.T) {
+
+	heuristic := NewHeuristicPlayer()
+
+	// O must block the top row
+	board := []Symbol{X, X, "", "", O, "", "", "", ""}
+	require.Equal(t, 2, heuristic.Move(GameState{Shape: Classic, Board: board}, O))
+
+	// winning beats blocking
+	board = []Symbol{X, X, "", O, O, "", "", "", ""}
+	require.Equal(t, 5, heuristic.Move(GameState{Shape: Classic, Board: board}, O))
+
+	require.Equal(t, 4, heuristic.Move(GameState{Shape: Classic, Board: make([]Symbol, 9)}, X))
+}
+
+func TestAIGame(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	_, err := ttt.CreateGame("bad", X, GameOptions{AI: "impossible"})
+	require.IsType(t, &UnknownDifficultyErr{}, err)
+
+	x, err := ttt.CreateGame("solo", X, GameOptions{AI: Hard})
+	require.NoError(t, err)
+
+	_, err = ttt.JoinGame("solo")
+	require.IsType(t, &TooManyPlayersErr{}, err)
+
+	state, err := ttt.Move("solo", X, x.Token, 0)
+	require.NoError(t, err)
+	require.Equal(t, X, state.Turn)
+	require.Equal(t, O, state.Board[4])
+
+	for state.Result == NoResult {
+		index := NewPerfectPlayer().Move(*state, X)
+		state, err = ttt.Move("solo", X, x.Token, index)
+		require.NoError(t, err)
+	}
+	require.Equal(t, Drawn, state.Result)
+}
+
+// slowPlayer plays the first empty cell once it is let go.
+type slowPlayer struct {
+	thinking chan struct{}
+	release  chan struct{}
+}
+
+func (p *slowPlayer) Move(state GameState, symbol Symbol) int {
+	p.thinking <- struct{}{}
+	<-p.release
+	for i, s := range state.Board {
+		if s == Empty {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestAIThinksWithoutLock(t *testing.T) {
+
+	tt := NewTicTacToe().(*ttt)
+
+	x, err := tt.CreateGame("solo", X, GameOptions{AI: Easy})
+	require.NoError(t, err)
+
+	slow := &slowPlayer{thinking: make(chan struct{}), release: make(chan struct{})}
+	g, err := tt.game("solo")
+	require.NoError(t, err)
+	g.ai = slow
+
+	moved := make(chan *GameState)
+	go func() {
+		state, err := tt.Move("solo", X, x.Token, 0)
+		require.NoError(t, err)
+		moved <- state
+	}()
+	<-slow.thinking
+
+	// the game can be read while the computer thinks
+	state, err := tt.GetGame("solo")
+	require.NoError(t, err)
+	require.Equal(t, O, state.Turn)
+	require.Equal(t, X, state.Board[0])
+
+	close(slow.release)
+	state = <-moved
+	require.Equal(t, X, state.Turn)
+	require.Equal(t, O, state.Board[1])
+}
+
+func TestPlayersOnLargeBoards(t *testing.T) {
+
+	shape := Shape{Width: 15, Height: 15, WinLength: 5}
+	board := make([]Symbol, shape.Cells())
+
+	// O has four on the top row, X has three on the second
+	for i := 0; i < 4; i++ {
+		board[i] = O
+	}
+	for i := 0; i < 3; i++ {
+		board[shape.Width+i] = X
+	}
+	board[2*shape.Width] = X
+
+	state := GameState{Shape: shape, Board: board}
+
+	for _, p := range []Player{NewHeuristicPlayer(), NewPerfectPlayer()} {
+
+		// X must block
+		require.Equal(t, 4, p.Move(state, X))
+
+		// O wins
+		require.Equal(t, 4, p.Move(state, O))
+	}
+
+	// with four of its own X wins rather than blocks
+	board[shape.Width+3] = X
+	for _, p := range []Player{NewHeuristicPlayer(), NewPerfectPlayer()} {
+		require.Equal(t, shape.Width+4, p.Move(state, X))
+	}
+
+	empty := GameState{Shape: shape, Board: make([]Symbol, shape.Cells())}
+	require.Equal(t, 7*shape.Width+7, NewHeuristicPlayer().Move(empty, X))
+}
+
+func TestPerfectPlayerGivesUp(t *testing.T) {
+
+	// far too many cells to search PerfectDepth plies
+	shape := Shape{Width: MaxBoardSize, Height: MaxBoardSize, WinLength: MaxBoardSize}
+	board := make([]Symbol, shape.Cells())
+	board[0], board[shape.Cells()-1] = X, O
+
+	search := &lookahead{shape: shape}
+	search.negamax(board, X, shape.Cells()-1, PerfectDepth, -scoreBound(board), scoreBound(board))
+	require.LessOrEqual(t, search.work, PerfectWork+(PerfectDepth+1)*shape.Cells())
+
+	// the player still moves, to the best placed cell
+	state := GameState{Shape: shape, Board: board}
+	index := NewPerfectPlayer().Move(state, X)
+	require.Equal(t, Empty, board[index])
+
+	// the classic board is searched to the end within the budget
+	search = &lookahead{shape: Classic}
+	classic := make([]Symbol, 9)
+	for i := range classic {
+		classic[i] = X
+		search.negamax(classic, O, i, 8, -scoreBound(classic), scoreBound(classic))
+		classic[i] = Empty
+	}
+	require.LessOrEqual(t, search.work, PerfectWork)
+}
+
+func BenchmarkPerfectPlayer(b *testing.B) {
+	perfect := NewPerfectPlayer()
+	state := GameState{Shape: Classic, Board: make([]Symbol, 9)}
+	for i := 0; i < b.N; i++ {
+		perfect.Move(state, X)
+	}
+}
+
+func BenchmarkHeuristicPlayer(b *testing.B) {
+	heuristic := NewHeuristicPlayer()
+	state := GameState{Shape: Classic, Board: make([]Symbol, 9)}
+	for i := 0; i < b.N; i++ {
+		heuristic.Move(state, X)
+	}
+}
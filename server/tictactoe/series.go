@@ -0,0 +1,136 @@
+package tictactoe
+
+// Series is the running score of the games played in one GameID.
+type Series struct {
+	// BestOf is the length of the series, zero when open ended
+	BestOf int `json:"best_of"`
+	// Round is the number of the current game, starting at 1
+	Round int            `json:"round"`
+	Wins  map[Symbol]int `json:"wins"`
+	Draws int            `json:"draws"`
+	// Winner is set once a player has won more than half of BestOf
+	Winner Symbol `json:"winner,omitempty"`
+}
+
+func newSeries(bestOf int) Series {
+	return Series{
+		BestOf: bestOf,
+		Round:  1,
+		Wins:   map[Symbol]int{X: 0, O: 0},
+	}
+}
+
+func (s Series) copy() Series {
+	wins := map[Symbol]int{}
+	for k, v := range s.Wins {
+		wins[k] = v
+	}
+	s.Wins = wins
+	return s
+}
+
+// record counts the result of the current game.
+func (s *Series) record(result Result, winner Symbol) {
+
+	if result == Drawn {
+		s.Draws++
+		return
+	}
+
+	if s.Wins == nil {
+		s.Wins = map[Symbol]int{}
+	}
+	s.Wins[winner]++
+
+	if s.BestOf > 0 && s.Wins[winner] > s.BestOf/2 {
+		s.Winner = winner
+	}
+}
+
+// finish settles the current game with the final status, counts it
+// in the series and publishes the state with event. Nobody can move
+// once the game has a result.
+func (g *game) finish(status Status, winner Symbol, event EventType) *GameState {
+
+	result := Won
+	if status == DrawnStatus {
+		result = Drawn
+	}
+
+	g.stopClock()
+	g.result = result
+	g.winner = winner
+	g.turn = Empty
+	g.takeback = Empty
+	g.series.record(result, winner)
+
+	if len(g.players) == 2 {
+		g.accounts.rate(g.players[X], g.players[O], result, winner)
+	}
+
+	return g.become(status, event)
+}
+
+// Rematch asks to play another game in the same GameID. Once both
+// players have asked the board is cleared and the player who did not
+// start the last game starts the next one. A computer opponent always
+// agrees.
+func (t *ttt) Rematch(id GameID, symbol Symbol, token string) (*GameState, error) {
+
+	game, err := t.game(id)
+	if err != nil {
+		return nil, err
+	}
+
+	game.mu.Lock()
+
+	if err := game.checkSeat(symbol, token); err != nil {
+		game.mu.Unlock()
+		return nil, err
+	}
+
+	if !game.status.Over() {
+		game.mu.Unlock()
+		return nil, &GameNotOverErr{}
+	}
+
+	if game.series.Winner != Empty {
+		game.mu.Unlock()
+		return nil, &SeriesOverErr{}
+	}
+
+	agreed := game.rematch == opponent(symbol) || (game.ai != nil && game.aiSymbol != symbol)
+	if !agreed {
+		game.rematch = symbol
+		state := game.publish(RematchRequestEvent)
+		game.mu.Unlock()
+		return state, nil
+	}
+
+	state := game.restart()
+	game.mu.Unlock()
+
+	// the computer may go first in the new game
+	return game.reply(state), nil
+}
+
+// restart clears the board for the next game of the series.
+func (g *game) restart() *GameState {
+
+	for i := range g.board {
+		g.board[i] = Empty
+	}
+	g.history = nil
+	g.result = NoResult
+	g.winner = Empty
+	g.rematch = Empty
+	g.takeback = Empty
+
+	g.starter = opponent(g.starter)
+	g.turn = g.starter
+	g.series.Round++
+
+	g.remaining = g.control.fullTime()
+
+	return g.become(Playing, RematchEvent)
+}
@@ -0,0 +1,142 @@
+package tictactoe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// winTopRow has symbol take the top row while the other
+// player fills the middle row, starting with whoever's turn it is.
+func winTopRow(t *testing.T, ttt TicTacToe, seats map[Symbol]*JoinResponse, winner Symbol) *GameState {
+
+	state, err := ttt.GetGame("series")
+	require.NoError(t, err)
+
+	cells := map[Symbol][]int{winner: {0, 1, 2}, opponent(winner): {3, 4, 6}}
+	for state.Result == NoResult {
+		turn := state.Turn
+		index := cells[turn][0]
+		cells[turn] = cells[turn][1:]
+
+		state, err = ttt.Move("series", turn, seats[turn].Token, index)
+		require.NoError(t, err)
+	}
+
+	return state
+}
+
+func TestSeries(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	_, err := ttt.CreateGame("bad", X, GameOptions{BestOf: -1})
+	require.IsType(t, &InvalidSeriesErr{}, err)
+
+	x, err := ttt.CreateGame("series", X, GameOptions{BestOf: 3})
+	require.NoError(t, err)
+	require.Equal(t, 3, x.State.Series.BestOf)
+	require.Equal(t, 1, x.State.Series.Round)
+
+	o, err := ttt.JoinGame("series")
+	require.NoError(t, err)
+
+	seats := map[Symbol]*JoinResponse{X: x, O: o}
+
+	_, err = ttt.Rematch("series", X, x.Token)
+	require.IsType(t, &GameNotOverErr{}, err)
+
+	state := winTopRow(t, ttt, seats, X)
+	require.Equal(t, 1, state.Series.Wins[X])
+
+	state, err = ttt.Rematch("series", O, o.Token)
+	require.NoError(t, err)
+	require.Equal(t, RematchRequestEvent, state.Event)
+	require.Equal(t, O, state.Rematch)
+
+	// asking twice does not start the game
+	state, err = ttt.Rematch("series", O, o.Token)
+	require.NoError(t, err)
+	require.Equal(t, RematchRequestEvent, state.Event)
+
+	state, err = ttt.Rematch("series", X, x.Token)
+	require.NoError(t, err)
+	require.Equal(t, RematchEvent, state.Event)
+	require.Equal(t, make([]Symbol, 9), state.Board)
+	require.Equal(t, NoResult, state.Result)
+	require.Equal(t, 2, state.Series.Round)
+
+	// the other player starts the rematch
+	require.Equal(t, O, state.Turn)
+
+	history, err := ttt.History("series")
+	require.NoError(t, err)
+	require.Empty(t, history.Moves)
+
+	state = winTopRow(t, ttt, seats, O)
+	require.Equal(t, map[Symbol]int{X: 1, O: 1}, state.Series.Wins)
+	require.Equal(t, Empty, state.Series.Winner)
+
+	_, err = ttt.Rematch("series", X, x.Token)
+	require.NoError(t, err)
+	state, err = ttt.Rematch("series", O, o.Token)
+	require.NoError(t, err)
+	require.Equal(t, X, state.Turn)
+
+	state = winTopRow(t, ttt, seats, X)
+	require.Equal(t, X, state.Series.Winner)
+
+	_, err = ttt.Rematch("series", O, o.Token)
+	require.IsType(t, &SeriesOverErr{}, err)
+}
+
+func TestRematchAgainstComputer(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	x, err := ttt.CreateGame("solo", X, GameOptions{AI: Medium})
+	require.NoError(t, err)
+
+	state, err := ttt.Resign("solo", X, x.Token)
+	require.NoError(t, err)
+	require.Equal(t, 1, state.Series.Wins[O])
+
+	// the computer agrees and, starting this time, moves at once
+	state, err = ttt.Rematch("solo", X, x.Token)
+	require.NoError(t, err)
+	require.Equal(t, X, state.Turn)
+	require.Equal(t, O, state.Board[4])
+	require.Equal(t, 2, state.Series.Round)
+}
+
+func TestSeriesRestore(t *testing.T) {
+
+	store := NewMemoryStore()
+
+	ttt, err := NewTicTacToeFromStore(store)
+	require.NoError(t, err)
+
+	x, err := ttt.CreateGame("series", X, GameOptions{BestOf: 5})
+	require.NoError(t, err)
+	o, err := ttt.JoinGame("series")
+	require.NoError(t, err)
+
+	winTopRow(t, ttt, map[Symbol]*JoinResponse{X: x, O: o}, X)
+
+	_, err = ttt.Rematch("series", O, o.Token)
+	require.NoError(t, err)
+
+	ttt, err = NewTicTacToeFromStore(store)
+	require.NoError(t, err)
+
+	state, err := ttt.GetGame("series")
+	require.NoError(t, err)
+	require.Equal(t, O, state.Rematch)
+	require.Equal(t, 5, state.Series.BestOf)
+	require.Equal(t, 1, state.Series.Wins[X])
+
+	// the starter survives too, so O starts the rematch
+	state, err = ttt.Rematch("series", X, x.Token)
+	require.NoError(t, err)
+	require.Equal(t, O, state.Turn)
+}
@@ -0,0 +1,50 @@
+package tictactoe
+
+// MessageType identifies a message sent over a game's WebSocket.
+type MessageType string
+
+// Messages sent by a player to the server.
+//
+//	{"type": "move", "index": 4}
+//	{"type": "resign"}
+//	{"type": "chat", "text": "good game"}
+//	{"type": "takeback"}
+//	{"type": "accept"}
+//	{"type": "decline"}
+//	{"type": "rematch"}
+//
+// Messages sent by the server to a player.
+//
+//	{"type": "state", "state": {...GameState}}
+//	{"type": "error", "error": "Not your turn", "code": "not_your_turn"}
+const (
+	MoveMessage   MessageType = "move"
+	ResignMessage MessageType = "resign"
+	ChatMessage   MessageType = "chat"
+	// TakebackMessage asks to take back the last move, which the
+	// opponent answers with AcceptMessage or DeclineMessage
+	TakebackMessage MessageType = "takeback"
+	AcceptMessage   MessageType = "accept"
+	DeclineMessage  MessageType = "decline"
+	// RematchMessage asks for another game once this one is over
+	RematchMessage MessageType = "rematch"
+	StateMessage   MessageType = "state"
+	ErrorMessage   MessageType = "error"
+)
+
+// ClientMessage is sent by a player over the socket. The seat it
+// acts for is the one the socket was opened with.
+type ClientMessage struct {
+	Type  MessageType `json:"type"`
+	Index int         `json:"index"`
+	Text  string      `json:"text,omitempty"`
+}
+
+// ServerMessage is sent to every socket of a game.
+type ServerMessage struct {
+	Type  MessageType `json:"type"`
+	State *GameState  `json:"state,omitempty"`
+	Error string      `json:"error,omitempty"`
+	// Code identifies the error, see ErrorFromCode
+	Code ErrorCode `json:"code,omitempty"`
+}
@@ -0,0 +1,473 @@
+package tictactoe
+
+import (
+	"bytes"
+	"sync"
+)
+
+// MaxSolveEmpty is the most empty cells a position may have to be
+// solved. Beyond it the game tree is too large to search in full.
+const MaxSolveEmpty = 12
+
+// maxMemoBytes bounds the memory a solver remembers positions in, it
+// starts over once it would take more. Keys grow with the board, so
+// it is the bytes that are counted and not the positions. Together
+// the shared solvers hold at most maxSolvers times as much.
+const maxMemoBytes = 1 << 23
+
+// memoEntryBytes is roughly what a remembered position costs on top
+// of its key: the string header, the entry and the map's own upkeep.
+const memoEntryBytes = 48
+
+// maxSolveNodes bounds the positions searched for one analysis. Some
+// boards have too large a game tree even with few empty cells left,
+// and are refused once the search gets this far.
+const maxSolveNodes = 1 << 18
+
+// maxSolvers bounds the shapes with a shared solver, the oldest is
+// dropped to make room for another.
+const maxSolvers = 8
+
+// Outcome is how a game ends for the player to move when both sides
+// play perfectly.
+type Outcome string
+
+const (
+	WinOutcome  Outcome = "win"
+	DrawOutcome Outcome = "draw"
+	LossOutcome Outcome = "loss"
+)
+
+// Evaluation is the value of a position, or of a move, for the player
+// to move.
+type Evaluation struct {
+	Outcome Outcome `json:"outcome"`
+	// Plies is how many moves are left in the game with perfect play,
+	// the winner winning as soon as it can and the loser holding out
+	// as long as it can
+	Plies int `json:"plies"`
+}
+
+// CellEvaluation is the value of playing a cell.
+type CellEvaluation struct {
+	Evaluation
+	Index int `json:"index"`
+}
+
+// Analysis is the game-theoretic value of a position.
+type Analysis struct {
+	Shape
+	Board []Symbol `json:"board"`
+	// Turn is the player to move, Empty once the game is over
+	Turn Symbol `json:"turn"`
+	// Winner is set when the position is already won
+	Winner Symbol `json:"winner,omitempty"`
+	// Evaluation is the value of the position for Turn
+	Evaluation
+	// Cells evaluates every empty cell for Turn, in board order
+	Cells []CellEvaluation `json:"cells"`
+	// Best are the cells that keep the best outcome, in board order
+	Best []int `json:"best"`
+}
+
+// Solver finds the value of positions on a board by searching the
+// game tree with alpha-beta pruning. Positions are remembered by a key
+// that is the same for every rotation and reflection of the board, so
+// each is only searched once. A Solver is safe for concurrent use.
+type Solver struct {
+	shape Shape
+	// symmetries map each cell of a transformed board to the cell of
+	// the board it comes from
+	symmetries [][]int
+
+	mu   sync.Mutex
+	memo map[string]memoEntry
+	// memoBytes is the memory taken by memo, see maxMemoBytes
+	memoBytes int
+}
+
+// memoEntry is a remembered score. A search cut short by pruning only
+// learns a bound on the score, which is remembered as such.
+type memoEntry struct {
+	score int
+	bound bound
+}
+
+type bound int
+
+const (
+	exactBound bound = iota
+	// lowerBound means the score is at least the one remembered
+	lowerBound
+	// upperBound means the score is at most the one remembered
+	upperBound
+)
+
+// search is one analysis with a solver, counting the positions it
+// visits against maxSolveNodes.
+type search struct {
+	*Solver
+	nodes   int
+	aborted bool
+}
+
+var (
+	solversMu sync.Mutex
+	// solvers are shared so positions are remembered across calls,
+	// shapes are kept in the order they were first asked for
+	solvers = map[Shape]*Solver{}
+	shapes  = []Shape{}
+)
+
+// Analyze solves the position with the shared solver of the shape,
+// see Solver.Analyze.
+func Analyze(shape Shape, board []Symbol, turn Symbol) (*Analysis, error) {
+
+	if shape == (Shape{}) {
+		shape = Classic
+	}
+	if err := shape.Valid(); err != nil {
+		return nil, err
+	}
+
+	solversMu.Lock()
+	solver, ok := solvers[shape]
+	if !ok {
+		if len(shapes) == maxSolvers {
+			delete(solvers, shapes[0])
+			shapes = shapes[1:]
+		}
+		solver = NewSolver(shape)
+		solvers[shape] = solver
+		shapes = append(shapes, shape)
+	}
+	solversMu.Unlock()
+
+	return solver.Analyze(board, turn)
+}
+
+// NewSolver returns a solver for positions on the board.
+func NewSolver(shape Shape) *Solver {
+	return &Solver{
+		shape:      shape,
+		symmetries: symmetries(shape),
+		memo:       map[string]memoEntry{},
+	}
+}
+
+// Analyze evaluates the position and each empty cell of it for the
+// player to move. Turn is worked out from the board when it is Empty,
+// X moving first when both have played as many cells. Boards with
+// more than MaxSolveEmpty empty cells, or that take more than
+// maxSolveNodes positions to search, are refused.
+func (s *Solver) Analyze(board []Symbol, turn Symbol) (*Analysis, error) {
+
+	turn, err := s.check(board, turn)
+	if err != nil {
+		return nil, err
+	}
+
+	board = append([]Symbol(nil), board...)
+	analysis := &Analysis{
+		Shape: s.shape,
+		Board: board,
+		Cells: []CellEvaluation{},
+		Best:  []int{},
+	}
+
+	for _, symbol := range []Symbol{X, O} {
+		if s.shape.IsWon(board, symbol) {
+			analysis.Winner = symbol
+			analysis.Outcome = LossOutcome
+			if symbol == turn {
+				analysis.Outcome = WinOutcome
+			}
+			return analysis, nil
+		}
+	}
+
+	cells := emptyCells(board)
+	if len(cells) == 0 {
+		analysis.Outcome = DrawOutcome
+		return analysis, nil
+	}
+	if len(cells) > MaxSolveEmpty {
+		return nil, &TooLargeToSolveErr{}
+	}
+
+	analysis.Turn = turn
+
+	search := &search{Solver: s}
+	bound := scoreBound(board)
+
+	best := -bound
+	for _, i := range cells {
+		// every cell is searched with the full window so its score is
+		// exact and not just a bound
+		score := search.play(board, turn, i, len(cells), -bound, bound)
+		if search.aborted {
+			return nil, &TooLargeToSolveErr{}
+		}
+
+		analysis.Cells = append(analysis.Cells, CellEvaluation{
+			Evaluation: evaluation(score, len(cells)),
+			Index:      i,
+		})
+
+		if score > best {
+			best, analysis.Best = score, []int{i}
+		} else if score == best {
+			analysis.Best = append(analysis.Best, i)
+		}
+	}
+
+	analysis.Evaluation = evaluation(best, len(cells))
+
+	return analysis, nil
+}
+
+// check validates the position and returns the player to move.
+func (s *Solver) check(board []Symbol, turn Symbol) (Symbol, error) {
+
+	if len(board) != s.shape.Cells() {
+		return Empty, &InvalidPositionErr{}
+	}
+	if turn != Empty && !turn.Valid() {
+		return Empty, &InvalidSymbolErr{}
+	}
+
+	counts := map[Symbol]int{}
+	for _, symbol := range board {
+		if symbol != Empty && !symbol.Valid() {
+			return Empty, &InvalidPositionErr{}
+		}
+		counts[symbol]++
+	}
+
+	// players take turns, so one is at most a cell ahead and it is
+	// the other's turn
+	switch counts[X] - counts[O] {
+	case 0:
+		if turn == Empty {
+			turn = X
+		}
+	case 1:
+		if turn == X {
+			return Empty, &InvalidPositionErr{}
+		}
+		turn = O
+	case -1:
+		if turn == O {
+			return Empty, &InvalidPositionErr{}
+		}
+		turn = X
+	default:
+		return Empty, &InvalidPositionErr{}
+	}
+
+	if s.shape.IsWon(board, X) && s.shape.IsWon(board, O) {
+		return Empty, &InvalidPositionErr{}
+	}
+
+	return turn, nil
+}
+
+// play scores symbol playing the empty cell i, with empty cells left
+// on the board before the move. Scores are positive for wins and
+// negative for losses, larger the sooner the game ends, and zero for
+// draws. Scores outside alpha and beta are only bounds.
+func (s *search) play(board []Symbol, symbol Symbol, i, empty, alpha, beta int) int {
+
+	board[i] = symbol
+	defer func() {
+		board[i] = Empty
+	}()
+
+	switch {
+	case s.shape.IsWonAt(board, symbol, i):
+		return empty
+	case empty == 1:
+		return 0
+	}
+
+	return -s.solve(board, opponent(symbol), empty-1, -beta, -alpha)
+}
+
+// solve scores the position for symbol to move, see play.
+func (s *search) solve(board []Symbol, symbol Symbol, empty, alpha, beta int) int {
+
+	if s.nodes++; s.nodes > maxSolveNodes {
+		s.aborted = true
+	}
+	if s.aborted {
+		return 0
+	}
+
+	key := s.key(board, symbol)
+	if entry, ok := s.lookup(key); ok {
+		switch entry.bound {
+		case exactBound:
+			return entry.score
+		case lowerBound:
+			if entry.score > alpha {
+				alpha = entry.score
+			}
+		case upperBound:
+			if entry.score < beta {
+				beta = entry.score
+			}
+		}
+		if alpha >= beta {
+			return entry.score
+		}
+	}
+
+	// nothing beats winning right away
+	if beta > empty {
+		beta = empty
+	}
+	if alpha >= beta {
+		return beta
+	}
+	window := alpha
+
+	best := -scoreBound(board)
+	for i, v := range board {
+		if v != Empty {
+			continue
+		}
+
+		if score := s.play(board, symbol, i, empty, alpha, beta); score > best {
+			best = score
+		}
+		if best > alpha {
+			alpha = best
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+
+	if s.aborted {
+		return 0
+	}
+
+	entry := memoEntry{score: best}
+	switch {
+	case best <= window:
+		entry.bound = upperBound
+	case best >= beta:
+		entry.bound = lowerBound
+	}
+	s.store(key, entry)
+
+	return best
+}
+
+// lookup returns the remembered score of a position.
+func (s *Solver) lookup(key string) (memoEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.memo[key]
+	return entry, ok
+}
+
+// store remembers the score of a position, an exact score is kept
+// over a bound.
+func (s *Solver) store(key string, entry memoEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.memo[key]
+	if ok && old.bound == exactBound {
+		return
+	}
+	if !ok {
+		size := len(key) + memoEntryBytes
+		if s.memoBytes+size > maxMemoBytes {
+			s.memo, s.memoBytes = map[string]memoEntry{}, 0
+		}
+		s.memoBytes += size
+	}
+	s.memo[key] = entry
+}
+
+// key identifies the position for symbol to move, the same for every
+// rotation and reflection of the board.
+func (s *Solver) key(board []Symbol, symbol Symbol) string {
+
+	// cells are encoded as in Hash, without building a string for
+	// every symmetry
+	cells := make([]byte, len(board))
+	for i, v := range board {
+		switch v {
+		case X:
+			cells[i] = '1'
+		case O:
+			cells[i] = '2'
+		default:
+			cells[i] = '-'
+		}
+	}
+
+	var key []byte
+	buf := make([]byte, len(board))
+	for _, symmetry := range s.symmetries {
+		for i, from := range symmetry {
+			buf[i] = cells[from]
+		}
+		if key == nil || bytes.Compare(buf, key) < 0 {
+			key = append(key[:0], buf...)
+		}
+	}
+
+	return string(symbol) + string(key)
+}
+
+// evaluation describes a score for the player to move with empty
+// cells left, see play.
+func evaluation(score, empty int) Evaluation {
+	switch {
+	case score > 0:
+		return Evaluation{Outcome: WinOutcome, Plies: empty - score + 1}
+	case score < 0:
+		return Evaluation{Outcome: LossOutcome, Plies: empty + score + 1}
+	}
+	return Evaluation{Outcome: DrawOutcome, Plies: empty}
+}
+
+// symmetries lists the rotations and reflections that map the board
+// onto itself, as the cell each cell is taken from. Every board can
+// be flipped either way, square boards can also be turned.
+func symmetries(shape Shape) [][]int {
+
+	w, h := shape.Width, shape.Height
+
+	transforms := []func(col, row int) (int, int){
+		func(c, r int) (int, int) { return c, r },
+		func(c, r int) (int, int) { return w - 1 - c, r },
+		func(c, r int) (int, int) { return c, h - 1 - r },
+		func(c, r int) (int, int) { return w - 1 - c, h - 1 - r },
+	}
+	if w == h {
+		transforms = append(transforms,
+			func(c, r int) (int, int) { return r, c },
+			func(c, r int) (int, int) { return w - 1 - r, c },
+			func(c, r int) (int, int) { return r, h - 1 - c },
+			func(c, r int) (int, int) { return w - 1 - r, h - 1 - c },
+		)
+	}
+
+	perms := [][]int{}
+	for _, transform := range transforms {
+		perm := make([]int, shape.Cells())
+		for i := range perm {
+			c, r := transform(i%w, i/w)
+			perm[i] = r*w + c
+		}
+		perms = append(perms, perm)
+	}
+
+	return perms
+}
@@ -0,0 +1,247 @@
+package tictactoe
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSolver(t *testing.T) {
+
+	solver := NewSolver(Classic)
+
+	// every opening draws
+	analysis, err := solver.Analyze(make([]Symbol, 9), Empty)
+	require.NoError(t, err)
+	require.Equal(t, X, analysis.Turn)
+	require.Equal(t, Evaluation{Outcome: DrawOutcome, Plies: 9}, analysis.Evaluation)
+	require.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8}, analysis.Best)
+
+	// X wins by completing the top row, anything but blocking the
+	// middle row lets O win
+	analysis, err = solver.Analyze([]Symbol{
+		X, X, Empty,
+		O, O, Empty,
+		Empty, Empty, Empty,
+	}, Empty)
+	require.NoError(t, err)
+	require.Equal(t, Evaluation{Outcome: WinOutcome, Plies: 1}, analysis.Evaluation)
+	require.Equal(t, []int{2}, analysis.Best)
+	for _, cell := range analysis.Cells {
+		if cell.Index != 2 && cell.Index != 5 {
+			require.Equal(t, Evaluation{Outcome: LossOutcome, Plies: 2}, cell.Evaluation, "cell %d", cell.Index)
+		}
+	}
+
+	// O in a corner answering a center opening draws, on an edge
+	// it loses
+	analysis, err = solver.Analyze([]Symbol{
+		Empty, Empty, Empty,
+		Empty, X, Empty,
+		Empty, Empty, Empty,
+	}, Empty)
+	require.NoError(t, err)
+	require.Equal(t, O, analysis.Turn)
+	require.Equal(t, DrawOutcome, analysis.Outcome)
+	require.Equal(t, []int{0, 2, 6, 8}, analysis.Best)
+	for _, cell := range analysis.Cells {
+		if cell.Index%2 == 1 {
+			require.Equal(t, LossOutcome, cell.Outcome, "cell %d", cell.Index)
+		}
+	}
+
+	// O moves first in the second game of a series
+	analysis, err = solver.Analyze(make([]Symbol, 9), O)
+	require.NoError(t, err)
+	require.Equal(t, O, analysis.Turn)
+}
+
+func TestSolverOver(t *testing.T) {
+
+	solver := NewSolver(Classic)
+
+	analysis, err := solver.Analyze([]Symbol{
+		X, X, X,
+		O, O, Empty,
+		Empty, Empty, Empty,
+	}, Empty)
+	require.NoError(t, err)
+	require.Equal(t, X, analysis.Winner)
+	require.Equal(t, Empty, analysis.Turn)
+	require.Equal(t, LossOutcome, analysis.Outcome)
+	require.Empty(t, analysis.Cells)
+
+	analysis, err = solver.Analyze([]Symbol{
+		X, O, X,
+		X, O, O,
+		O, X, X,
+	}, Empty)
+	require.NoError(t, err)
+	require.Equal(t, Empty, analysis.Winner)
+	require.Equal(t, DrawOutcome, analysis.Outcome)
+}
+
+func TestSolverRefuses(t *testing.T) {
+
+	solver := NewSolver(Classic)
+
+	for _, tc := range []struct {
+		name  string
+		board []Symbol
+		turn  Symbol
+		err   error
+	}{
+		{"wrong size", make([]Symbol, 8), Empty, &InvalidPositionErr{}},
+		{"unknown symbol", []Symbol{"Z", "", "", "", "", "", "", "", ""}, Empty, &InvalidPositionErr{}},
+		{"too many X", []Symbol{X, X, "", "", "", "", "", "", ""}, Empty, &InvalidPositionErr{}},
+		{"out of turn", []Symbol{X, "", "", "", "", "", "", "", ""}, X, &InvalidPositionErr{}},
+		{"both won", []Symbol{X, X, X, O, O, O, "", "", ""}, Empty, &InvalidPositionErr{}},
+		{"bad turn", make([]Symbol, 9), "Z", &InvalidSymbolErr{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := solver.Analyze(tc.board, tc.turn)
+			require.IsType(t, tc.err, err)
+		})
+	}
+
+	_, err := NewSolver(Shape{Width: 4, Height: 4, WinLength: 3}).Analyze(make([]Symbol, 16), Empty)
+	require.IsType(t, &TooLargeToSolveErr{}, err)
+
+	_, err = Analyze(Shape{Width: 1, Height: 1, WinLength: 5}, make([]Symbol, 1), Empty)
+	require.IsType(t, &InvalidBoardErr{}, err)
+}
+
+func TestSolverSymmetry(t *testing.T) {
+
+	solver := NewSolver(Shape{Width: 4, Height: 3, WinLength: 3})
+
+	board := []Symbol{
+		X, Empty, Empty, Empty,
+		Empty, O, Empty, Empty,
+		Empty, Empty, Empty, Empty,
+	}
+	// flipped left to right and top to bottom
+	flipped := []Symbol{
+		Empty, Empty, Empty, Empty,
+		Empty, Empty, O, Empty,
+		Empty, Empty, Empty, X,
+	}
+	// turned a quarter, which does not fit a 4x3 board
+	turned := []Symbol{
+		Empty, Empty, Empty, X,
+		Empty, Empty, O, Empty,
+		Empty, Empty, Empty, Empty,
+	}
+
+	require.Equal(t, solver.key(board, X), solver.key(flipped, X))
+	require.NotEqual(t, solver.key(board, X), solver.key(board, O))
+	require.Len(t, solver.symmetries, 4)
+	require.Len(t, NewSolver(Classic).symmetries, 8)
+
+	a, err := solver.Analyze(board, Empty)
+	require.NoError(t, err)
+	b, err := solver.Analyze(flipped, Empty)
+	require.NoError(t, err)
+	require.Equal(t, a.Evaluation, b.Evaluation)
+
+	_, err = solver.Analyze(turned, Empty)
+	require.NoError(t, err)
+}
+
+func TestSolverAgreesWithPerfectPlayer(t *testing.T) {
+
+	solver := NewSolver(Classic)
+	perfect := NewPerfectPlayer()
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	for game := 0; game < 50; game++ {
+
+		board := make([]Symbol, 9)
+		symbol := X
+		for len(emptyCells(board)) > 0 && !Classic.IsWon(board, opponent(symbol)) {
+
+			analysis, err := solver.Analyze(board, symbol)
+			require.NoError(t, err)
+
+			index := perfect.Move(GameState{Shape: Classic, Board: board}, symbol)
+			require.Contains(t, analysis.Best, index, "board %s", Hash(board))
+
+			// wander off the best line now and then
+			cells := emptyCells(board)
+			if r.Intn(2) == 0 {
+				index = cells[r.Intn(len(cells))]
+			}
+			board[index] = symbol
+			symbol = opponent(symbol)
+		}
+	}
+}
+
+func TestSolverGivesUp(t *testing.T) {
+
+	solver := NewSolver(Shape{Width: 4, Height: 4, WinLength: 4})
+	board := make([]Symbol, 16)
+	board[0], board[5], board[10], board[15] = X, O, X, O
+
+	// a search out of budget stops without remembering anything
+	search := &search{Solver: solver, nodes: maxSolveNodes}
+	search.solve(board, X, 12, -scoreBound(board), scoreBound(board))
+	require.True(t, search.aborted)
+	require.Empty(t, solver.memo)
+
+	// the shared solvers are bounded
+	for width := 3; width < 3+maxSolvers+2; width++ {
+		_, err := Analyze(Shape{Width: width, Height: 1, WinLength: 3}, make([]Symbol, width), Empty)
+		require.NoError(t, err)
+	}
+	require.Len(t, solvers, maxSolvers)
+	require.Len(t, shapes, maxSolvers)
+}
+
+func TestSolverMemoBound(t *testing.T) {
+
+	shape := Shape{Width: MaxBoardSize, Height: MaxBoardSize, WinLength: MaxBoardSize}
+	solver := NewSolver(shape)
+	board := make([]Symbol, shape.Cells())
+
+	// each key takes a cell more than the board, far more than on the
+	// classic board, and is still counted in full
+	key := solver.key(board, X)
+	size := len(key) + memoEntryBytes
+	last := ""
+	for i := 0; i < 2*maxMemoBytes/size; i++ {
+		last = key + string(rune(i))
+		solver.store(last, memoEntry{})
+		require.LessOrEqual(t, solver.memoBytes, maxMemoBytes)
+	}
+	require.NotEmpty(t, solver.memo)
+
+	// a position remembered again takes no more room
+	bytes := solver.memoBytes
+	solver.store(last, memoEntry{bound: lowerBound})
+	require.Equal(t, bytes, solver.memoBytes)
+}
+
+func TestParseHash(t *testing.T) {
+
+	board := []Symbol{X, Empty, O, Empty}
+	parsed, err := ParseHash(Hash(board))
+	require.NoError(t, err)
+	require.Equal(t, board, parsed)
+
+	_, err = ParseHash("1-3")
+	require.IsType(t, &InvalidPositionErr{}, err)
+}
+
+func BenchmarkSolver(b *testing.B) {
+
+	shape := Shape{Width: 4, Height: 4, WinLength: 3}
+	board := make([]Symbol, 16)
+	board[0], board[5], board[10], board[15] = X, O, X, O
+
+	for i := 0; i < b.N; i++ {
+		NewSolver(shape).Analyze(board, Empty)
+	}
+}
@@ -0,0 +1,323 @@
+package tictactoe
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store keeps games outside of the process so they survive restarts.
+// Records handed to and returned from a Store are owned by the caller.
+type Store interface {
+	// Create saves a new game, failing with GameExistsErr if the
+	// id is taken.
+	Create(record *GameRecord) error
+	// Load returns a game or GameNotFoundErr.
+	Load(id GameID) (*GameRecord, error)
+	// Save overwrites a game.
+	Save(record *GameRecord) error
+	List() ([]GameID, error)
+	Delete(id GameID) error
+
+	// SaveAccount creates or overwrites a player's account.
+	SaveAccount(record *AccountRecord) error
+	ListAccounts() ([]*AccountRecord, error)
+}
+
+// GameRecord is everything needed to restore a game.
+type GameRecord struct {
+	ID       GameID            `json:"id"`
+	Shape    Shape             `json:"shape"`
+	Board    []Symbol          `json:"board"`
+	Turn     Symbol            `json:"turn"`
+	Result   Result            `json:"result"`
+	Status   Status            `json:"status"`
+	Winner   Symbol            `json:"winner"`
+	Tokens   map[Symbol]string `json:"tokens"`
+	Players  map[Symbol]string `json:"players"`
+	History  []MoveRecord      `json:"history"`
+	Takeback Symbol            `json:"takeback"`
+	Rematch  Symbol            `json:"rematch"`
+	Starter  Symbol            `json:"starter"`
+	Series   Series            `json:"series"`
+	Created  time.Time         `json:"created"`
+	// Control is the time control and Remaining the time each
+	// player had left when their clock last stopped
+	Control   TimeControl              `json:"time_control"`
+	Remaining map[Symbol]time.Duration `json:"remaining"`
+	AI        Difficulty               `json:"ai"`
+	AISymbol  Symbol                   `json:"ai_symbol"`
+	// Seq is the last published sequence number, so it keeps
+	// increasing after a restore
+	Seq uint64 `json:"seq"`
+}
+
+// AccountRecord is a player's account as it is stored. Only a hash of
+// the key is kept.
+type AccountRecord struct {
+	Profile
+	KeyHash string `json:"key_hash"`
+}
+
+func (r *GameRecord) copy() *GameRecord {
+	c := *r
+	c.Board = make([]Symbol, len(r.Board))
+	copy(c.Board, r.Board)
+	c.History = make([]MoveRecord, len(r.History))
+	copy(c.History, r.History)
+	c.Series = r.Series.copy()
+	c.Remaining = map[Symbol]time.Duration{}
+	for k, v := range r.Remaining {
+		c.Remaining[k] = v
+	}
+	c.Tokens = map[Symbol]string{}
+	for k, v := range r.Tokens {
+		c.Tokens[k] = v
+	}
+	c.Players = map[Symbol]string{}
+	for k, v := range r.Players {
+		c.Players[k] = v
+	}
+	return &c
+}
+
+// NewMemoryStore keeps games in memory, so they do not survive a
+// restart. It is the store behind NewTicTacToe.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		records:  map[GameID]*GameRecord{},
+		accounts: map[string]AccountRecord{},
+	}
+}
+
+type memoryStore struct {
+	mu       sync.Mutex
+	records  map[GameID]*GameRecord
+	accounts map[string]AccountRecord
+}
+
+func (m *memoryStore) Create(record *GameRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.records[record.ID]; ok {
+		return &GameExistsErr{}
+	}
+	m.records[record.ID] = record.copy()
+	return nil
+}
+
+func (m *memoryStore) Load(id GameID) (*GameRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.records[id]
+	if !ok {
+		return nil, &GameNotFoundErr{}
+	}
+	return record.copy(), nil
+}
+
+func (m *memoryStore) Save(record *GameRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.records[record.ID] = record.copy()
+	return nil
+}
+
+func (m *memoryStore) List() ([]GameID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := []GameID{}
+	for id := range m.records {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (m *memoryStore) Delete(id GameID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.records, id)
+	return nil
+}
+
+func (m *memoryStore) SaveAccount(record *AccountRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.accounts[record.Name] = *record
+	return nil
+}
+
+func (m *memoryStore) ListAccounts() ([]*AccountRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	records := []*AccountRecord{}
+	for _, record := range m.accounts {
+		record := record
+		records = append(records, &record)
+	}
+	return records, nil
+}
+
+// NewFileStore keeps each game as a JSON file in dir, which is
+// created if needed. Accounts are kept the same way in the players
+// directory inside it.
+func NewFileStore(dir string) (Store, error) {
+	if err := os.MkdirAll(filepath.Join(dir, fileStorePlayers), 0755); err != nil {
+		return nil, err
+	}
+	return &fileStore{dir: dir}, nil
+}
+
+const (
+	fileStoreExt     = ".json"
+	fileStorePlayers = "players"
+)
+
+type fileStore struct {
+	// mu makes Create's existence check and write atomic
+	mu  sync.Mutex
+	dir string
+}
+
+// path escapes the id so any game name is a safe file name.
+func (f *fileStore) path(id GameID) string {
+	return filepath.Join(f.dir, url.PathEscape(string(id))+fileStoreExt)
+}
+
+func (f *fileStore) Create(record *GameRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := os.Stat(f.path(record.ID)); err == nil {
+		return &GameExistsErr{}
+	}
+	return f.write(f.path(record.ID), record)
+}
+
+func (f *fileStore) Load(id GameID) (*GameRecord, error) {
+
+	data, err := ioutil.ReadFile(f.path(id))
+	if os.IsNotExist(err) {
+		return nil, &GameNotFoundErr{}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	record := &GameRecord{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (f *fileStore) Save(record *GameRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.write(f.path(record.ID), record)
+}
+
+// write replaces the file in one rename so a crash never leaves
+// a half written game behind.
+func (f *fileStore) write(path string, record interface{}) error {
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (f *fileStore) List() ([]GameID, error) {
+
+	files, err := ioutil.ReadDir(f.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []GameID{}
+	for _, file := range files {
+		name := file.Name()
+		// temporary files have no extension
+		if file.IsDir() || !strings.HasSuffix(name, fileStoreExt) {
+			continue
+		}
+
+		id, err := url.PathUnescape(strings.TrimSuffix(name, fileStoreExt))
+		if err != nil {
+			continue
+		}
+		ids = append(ids, GameID(id))
+	}
+	return ids, nil
+}
+
+func (f *fileStore) Delete(id GameID) error {
+	err := os.Remove(f.path(id))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (f *fileStore) SaveAccount(record *AccountRecord) error {
+	path := filepath.Join(f.dir, fileStorePlayers, url.PathEscape(record.Name)+fileStoreExt)
+	return f.write(path, record)
+}
+
+func (f *fileStore) ListAccounts() ([]*AccountRecord, error) {
+
+	dir := filepath.Join(f.dir, fileStorePlayers)
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []*AccountRecord{}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), fileStoreExt) {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		record := &AccountRecord{}
+		if err := json.Unmarshal(data, record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
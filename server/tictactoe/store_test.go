@@ -0,0 +1,140 @@
+package tictactoe
+
+import (
+	"io/ioutil"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func tempFileStore(t *testing.T) Store {
+	dir, err := ioutil.TempDir("", "ttt-store")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	store, err := NewFileStore(dir)
+	require.NoError(t, err)
+	return store
+}
+
+func TestStores(t *testing.T) {
+
+	stores := map[string]Store{
+		"memory": NewMemoryStore(),
+		"file":   tempFileStore(t),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+
+			record := &GameRecord{
+				ID:     "a/../game, with odd name",
+				Shape:  Classic,
+				Board:  make([]Symbol, 9),
+				Turn:   X,
+				Tokens: map[Symbol]string{X: "secret"},
+			}
+
+			require.NoError(t, store.Create(record))
+			require.IsType(t, &GameExistsErr{}, store.Create(record))
+			require.NoError(t, store.Create(&GameRecord{ID: "other"}))
+
+			// the store keeps its own copy
+			record.Board[4] = X
+
+			loaded, err := store.Load(record.ID)
+			require.NoError(t, err)
+			require.Equal(t, Empty, loaded.Board[4])
+			require.Equal(t, "secret", loaded.Tokens[X])
+
+			require.NoError(t, store.Save(record))
+			loaded, err = store.Load(record.ID)
+			require.NoError(t, err)
+			require.Equal(t, X, loaded.Board[4])
+
+			ids, err := store.List()
+			require.NoError(t, err)
+			sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+			require.Equal(t, []GameID{record.ID, "other"}, ids)
+
+			require.NoError(t, store.Delete(record.ID))
+			require.NoError(t, store.Delete(record.ID))
+
+			_, err = store.Load(record.ID)
+			require.IsType(t, &GameNotFoundErr{}, err)
+
+			account := &AccountRecord{Profile: Profile{Name: "alice", Rating: 1200}}
+			require.NoError(t, store.SaveAccount(account))
+			account.Rating = 1216
+			require.NoError(t, store.SaveAccount(account))
+
+			accounts, err := store.ListAccounts()
+			require.NoError(t, err)
+			require.Len(t, accounts, 1)
+			require.Equal(t, 1216, accounts[0].Rating)
+		})
+	}
+}
+
+func TestRestore(t *testing.T) {
+
+	store := tempFileStore(t)
+
+	ttt, err := NewTicTacToeFromStore(store)
+	require.NoError(t, err)
+
+	shape := Shape{Width: 4, Height: 4, WinLength: 3}
+
+	x, err := ttt.CreateGame("kept", X, GameOptions{Shape: shape})
+	require.NoError(t, err)
+
+	o, err := ttt.JoinGame("kept")
+	require.NoError(t, err)
+
+	_, err = ttt.Move("kept", X, x.Token, 0)
+	require.NoError(t, err)
+
+	before, err := ttt.Move("kept", O, o.Token, 5)
+	require.NoError(t, err)
+
+	solo, err := ttt.CreateGame("solo", X, GameOptions{AI: Hard})
+	require.NoError(t, err)
+
+	ended, err := ttt.CreateGame("ended", X, GameOptions{})
+	require.NoError(t, err)
+	require.NoError(t, ttt.EndGame("ended", ended.Token))
+
+	// as if the server restarted
+	ttt, err = NewTicTacToeFromStore(store)
+	require.NoError(t, err)
+
+	ids := ttt.ListGames()
+	sort.Strings(ids)
+	require.Equal(t, []string{"kept", "solo"}, ids)
+
+	after, err := ttt.GetGame("kept")
+	require.NoError(t, err)
+	require.Equal(t, before.Board, after.Board)
+	require.Equal(t, shape, after.Shape)
+	require.Equal(t, X, after.Turn)
+	require.Equal(t, before.Seq, after.Seq)
+
+	history, err := ttt.History("kept")
+	require.NoError(t, err)
+	require.Len(t, history.Moves, 2)
+	require.Equal(t, 5, history.Moves[1].Index)
+
+	_, err = ttt.JoinGame("kept")
+	require.IsType(t, &TooManyPlayersErr{}, err)
+
+	state, err := ttt.Move("kept", X, x.Token, 1)
+	require.NoError(t, err)
+	require.Equal(t, before.Seq+1, state.Seq)
+
+	// the computer still replies
+	state, err = ttt.Move("solo", X, solo.Token, 0)
+	require.NoError(t, err)
+	require.Equal(t, O, state.Board[4])
+}
@@ -0,0 +1,73 @@
+package tictactoe
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Strategy picks the moves of a bot.
+type Strategy interface {
+	// Move returns the index of the cell symbol plays next on the
+	// board of state. It is only called while there is an empty cell.
+	Move(state GameState, symbol Symbol) int
+}
+
+// StrategyFunc lets an ordinary function be a Strategy.
+type StrategyFunc func(state GameState, symbol Symbol) int
+
+func (f StrategyFunc) Move(state GameState, symbol Symbol) int {
+	return f(state, symbol)
+}
+
+var (
+	strategiesMu sync.RWMutex
+	// strategies makes a new strategy by name, so strategies that
+	// keep state between moves are not shared between games
+	strategies = map[string]func() Strategy{}
+)
+
+func init() {
+	RegisterStrategy("random", NewRandomPlayer)
+	RegisterStrategy("heuristic", NewHeuristicPlayer)
+	RegisterStrategy("perfect", NewPerfectPlayer)
+}
+
+// RegisterStrategy makes a strategy available by name, typically
+// from the init function of the package implementing it. It panics
+// when the name is already taken.
+func RegisterStrategy(name string, new func() Strategy) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+
+	if _, ok := strategies[name]; ok {
+		panic(fmt.Sprintf("tictactoe: strategy %s registered twice", name))
+	}
+	strategies[name] = new
+}
+
+// NewStrategy returns a new instance of the strategy registered with
+// the name.
+func NewStrategy(name string) (Strategy, error) {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+
+	new, ok := strategies[name]
+	if !ok {
+		return nil, &UnknownStrategyErr{}
+	}
+	return new(), nil
+}
+
+// StrategyNames lists the registered strategies in alphabetical order.
+func StrategyNames() []string {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,39 @@
+package tictactoe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrategyRegistry(t *testing.T) {
+
+	require.Equal(t, []string{"heuristic", "perfect", "random"}, StrategyNames())
+
+	perfect, err := NewStrategy("perfect")
+	require.NoError(t, err)
+	require.IsType(t, &perfectPlayer{}, perfect)
+
+	_, err = NewStrategy("clairvoyant")
+	require.IsType(t, &UnknownStrategyErr{}, err)
+
+	// the first empty cell
+	RegisterStrategy("first", func() Strategy {
+		return StrategyFunc(func(state GameState, symbol Symbol) int {
+			return emptyCells(state.Board)[0]
+		})
+	})
+	defer func() {
+		strategiesMu.Lock()
+		delete(strategies, "first")
+		strategiesMu.Unlock()
+	}()
+
+	first, err := NewStrategy("first")
+	require.NoError(t, err)
+	require.Equal(t, 1, first.Move(GameState{Shape: Classic, Board: []Symbol{X, Empty, O}}, X))
+
+	require.Panics(t, func() {
+		RegisterStrategy("first", NewRandomPlayer)
+	})
+}
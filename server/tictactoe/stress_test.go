@@ -0,0 +1,149 @@
+package tictactoe
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// These tests are meant to be run with -race.
+
+func TestConcurrentCreateAndJoin(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		created int
+		joined  int
+	)
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ttt.CreateGame("contested", X, GameOptions{}); err == nil {
+				mu.Lock()
+				created++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ttt.JoinGame("contested"); err == nil {
+				mu.Lock()
+				joined++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, 1, created)
+	require.Equal(t, 1, joined)
+}
+
+func TestConcurrentGames(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	var (
+		players sync.WaitGroup
+		readers sync.WaitGroup
+		done    = make(chan struct{})
+	)
+
+	for g := 0; g < 20; g++ {
+
+		id := GameID(fmt.Sprintf("game-%d", g))
+
+		x, err := ttt.CreateGame(id, X, GameOptions{})
+		require.NoError(t, err)
+
+		for s := 0; s < 3; s++ {
+			ctx, cancel := context.WithCancel(context.Background())
+			policy := OverflowPolicy(s % 2)
+
+			// a small buffer makes the overflow policies kick in
+			sub, err := ttt.Subscribe(ctx, id, 1, policy)
+			require.NoError(t, err)
+
+			readers.Add(1)
+			go func() {
+				defer readers.Done()
+				defer cancel()
+				for {
+					select {
+					case _, ok := <-sub.Events():
+						if !ok {
+							return
+						}
+					case <-done:
+						return
+					}
+				}
+			}()
+		}
+
+		o, err := ttt.JoinGame(id)
+		require.NoError(t, err)
+
+		seats := []*JoinResponse{x, o}
+		for _, seat := range seats {
+			seat := seat
+			players.Add(1)
+			go func() {
+				defer players.Done()
+				play(ttt, id, seat)
+			}()
+		}
+
+		// spectators listing and polling while the game is played
+		players.Add(1)
+		go func() {
+			defer players.Done()
+			for i := 0; i < 50; i++ {
+				ttt.ListGames()
+				if state, err := ttt.GetGame(id); err == nil {
+					Hash(state.Board)
+				}
+				runtime.Gosched()
+			}
+			ttt.EndGame(id, x.Token)
+		}()
+	}
+
+	players.Wait()
+	close(done)
+	readers.Wait()
+
+	require.Empty(t, ttt.ListGames())
+}
+
+// play makes random moves for the seat until the game is over.
+func play(ttt TicTacToe, id GameID, seat *JoinResponse) {
+	for {
+		state, err := ttt.GetGame(id)
+		if err != nil || state.Result != NoResult {
+			return
+		}
+
+		if state.Turn != seat.Symbol {
+			runtime.Gosched()
+			continue
+		}
+
+		ttt.Move(id, seat.Symbol, seat.Token, rand.Intn(len(state.Board)))
+	}
+}
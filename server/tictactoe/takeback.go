@@ -0,0 +1,96 @@
+package tictactoe
+
+// RequestTakeback asks the opponent to allow the player to take back
+// their last move. A computer opponent always agrees.
+func (t *ttt) RequestTakeback(id GameID, symbol Symbol, token string) (*GameState, error) {
+
+	game, err := t.game(id)
+	if err != nil {
+		return nil, err
+	}
+
+	game.mu.Lock()
+	defer game.mu.Unlock()
+
+	if err := game.checkSeat(symbol, token); err != nil {
+		return nil, err
+	}
+
+	if err := game.checkPlaying(); err != nil {
+		return nil, err
+	}
+
+	if game.lastMove(symbol) < 0 {
+		return nil, &NothingToTakeBackErr{}
+	}
+
+	game.takeback = symbol
+
+	if game.ai != nil && game.aiSymbol != symbol {
+		return game.rewind(), nil
+	}
+
+	return game.publish(TakebackRequestEvent), nil
+}
+
+// AnswerTakeback accepts or declines the opponent's takeback request.
+// Accepting rewinds the board to just before the opponent's last move,
+// taking back any reply to it as well.
+func (t *ttt) AnswerTakeback(id GameID, symbol Symbol, token string, accept bool) (*GameState, error) {
+
+	game, err := t.game(id)
+	if err != nil {
+		return nil, err
+	}
+
+	game.mu.Lock()
+	defer game.mu.Unlock()
+
+	if err := game.checkSeat(symbol, token); err != nil {
+		return nil, err
+	}
+
+	if game.takeback != opponent(symbol) {
+		return nil, &NoTakebackErr{}
+	}
+
+	if !accept {
+		game.takeback = Empty
+		return game.publish(TakebackDeclinedEvent), nil
+	}
+
+	return game.rewind(), nil
+}
+
+// rewind takes back the moves up to and including the last move of
+// the player who asked for it and gives them the turn.
+func (g *game) rewind() *GameState {
+
+	symbol := g.takeback
+	last := g.lastMove(symbol)
+
+	// charge the player to move before the turn changes hands
+	g.stopClock()
+
+	for _, move := range g.history[last:] {
+		g.board[move.Index] = Empty
+	}
+	g.history = g.history[:last]
+
+	g.turn = symbol
+	g.takeback = Empty
+	g.startClock()
+
+	return g.publish(TakebackEvent)
+}
+
+// lastMove returns the position in the history of the symbol's
+// last move, or -1 if it has not moved.
+func (g *game) lastMove(symbol Symbol) int {
+	for i := len(g.history) - 1; i >= 0; i-- {
+		if g.history[i].Symbol == symbol {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,91 @@
+package tictactoe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTakeback(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	x, err := ttt.CreateGame("oops", X, GameOptions{})
+	require.NoError(t, err)
+
+	o, err := ttt.JoinGame("oops")
+	require.NoError(t, err)
+
+	_, err = ttt.RequestTakeback("oops", X, x.Token)
+	require.IsType(t, &NothingToTakeBackErr{}, err)
+
+	_, err = ttt.Move("oops", X, x.Token, 4)
+	require.NoError(t, err)
+
+	// O has nothing to answer yet
+	_, err = ttt.AnswerTakeback("oops", O, o.Token, true)
+	require.IsType(t, &NoTakebackErr{}, err)
+
+	state, err := ttt.RequestTakeback("oops", X, x.Token)
+	require.NoError(t, err)
+	require.Equal(t, TakebackRequestEvent, state.Event)
+	require.Equal(t, X, state.Takeback)
+
+	// X cannot answer their own request
+	_, err = ttt.AnswerTakeback("oops", X, x.Token, true)
+	require.IsType(t, &NoTakebackErr{}, err)
+
+	state, err = ttt.AnswerTakeback("oops", O, o.Token, false)
+	require.NoError(t, err)
+	require.Equal(t, TakebackDeclinedEvent, state.Event)
+	require.Equal(t, Empty, state.Takeback)
+	require.Equal(t, X, state.Board[4])
+
+	// after O replies X takes back both moves
+	_, err = ttt.Move("oops", O, o.Token, 0)
+	require.NoError(t, err)
+
+	_, err = ttt.RequestTakeback("oops", X, x.Token)
+	require.NoError(t, err)
+
+	state, err = ttt.AnswerTakeback("oops", O, o.Token, true)
+	require.NoError(t, err)
+	require.Equal(t, TakebackEvent, state.Event)
+	require.Equal(t, make([]Symbol, 9), state.Board)
+	require.Equal(t, X, state.Turn)
+
+	history, err := ttt.History("oops")
+	require.NoError(t, err)
+	require.Empty(t, history.Moves)
+
+	// a request is dropped when the opponent plays on
+	_, err = ttt.Move("oops", X, x.Token, 8)
+	require.NoError(t, err)
+
+	_, err = ttt.RequestTakeback("oops", X, x.Token)
+	require.NoError(t, err)
+
+	state, err = ttt.Move("oops", O, o.Token, 0)
+	require.NoError(t, err)
+	require.Equal(t, Empty, state.Takeback)
+
+	_, err = ttt.AnswerTakeback("oops", O, o.Token, true)
+	require.IsType(t, &NoTakebackErr{}, err)
+}
+
+func TestTakebackAgainstComputer(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	x, err := ttt.CreateGame("solo", X, GameOptions{AI: Hard})
+	require.NoError(t, err)
+
+	_, err = ttt.Move("solo", X, x.Token, 0)
+	require.NoError(t, err)
+
+	state, err := ttt.RequestTakeback("solo", X, x.Token)
+	require.NoError(t, err)
+	require.Equal(t, TakebackEvent, state.Event)
+	require.Equal(t, make([]Symbol, 9), state.Board)
+	require.Equal(t, X, state.Turn)
+}
@@ -1,7 +1,13 @@
 package tictactoe
 
 import (
+	"context"
+	"crypto/subtle"
+	"log"
 	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
 )
 
 type Symbol string
@@ -15,53 +21,278 @@ const (
 type EventType int
 
 const (
-	NoEvent    EventType = 0
-	WinEvent   EventType = 1
-	MoveEvent  EventType = 2
-	EndedEvent EventType = 3
+	NoEvent     EventType = 0
+	WinEvent    EventType = 1
+	MoveEvent   EventType = 2
+	EndedEvent  EventType = 3
+	DrawEvent   EventType = 4
+	ResignEvent EventType = 5
+	ChatEvent   EventType = 6
+	// TakebackRequestEvent asks the opponent to allow a takeback
+	TakebackRequestEvent  EventType = 7
+	TakebackEvent         EventType = 8
+	TakebackDeclinedEvent EventType = 9
+	// RematchRequestEvent asks the opponent to play again
+	RematchRequestEvent EventType = 10
+	RematchEvent        EventType = 11
+	// TimeoutEvent ends a timed game as a loss for the player
+	// whose time ran out
+	TimeoutEvent EventType = 12
+	// ClaimEvent puts a player's name on a seat
+	ClaimEvent EventType = 13
+	// JoinEvent starts the game once the second seat is taken
+	JoinEvent EventType = 14
+)
+
+// Result is the outcome of a game, empty while it is still being played.
+type Result string
+
+const (
+	NoResult Result = ""
+	Won      Result = "won"
+	Drawn    Result = "drawn"
 )
 
 type GameState struct {
+	Shape
 	ID     GameID    `json:"id"`
 	Event  EventType `json:"event"`
 	Board  []Symbol  `json:"board"`
 	Turn   Symbol    `json:"turn"`
 	Winner Symbol    `json:"winner"`
+	Result Result    `json:"result"`
+	Status Status    `json:"status"`
+	// Seq numbers the states published for a game, starting at 1. A
+	// snapshot carries the number of the latest published state.
+	Seq uint64 `json:"seq"`
+	// Takeback is the player waiting for an answer to a takeback
+	// request, if any
+	Takeback Symbol `json:"takeback,omitempty"`
+	// Rematch is the player waiting for the opponent to agree
+	// to play again, if any
+	Rematch Symbol `json:"rematch,omitempty"`
+	Series  Series `json:"series"`
+	// Players names the seats claimed by registered players
+	Players map[Symbol]string `json:"players,omitempty"`
+	// Spectators is the number of people watching the game
+	Spectators int `json:"spectators"`
+	// Clock is only set on timed games
+	Clock *Clock `json:"clock,omitempty"`
+	// Chat is only set on ChatEvent
+	Chat *Chat `json:"chat,omitempty"`
+}
+
+type Chat struct {
+	From Symbol `json:"from"`
+	Text string `json:"text"`
 }
 
 type GameID string
 
+// TokenHeader is the HTTP header a player sends their seat token in.
+const TokenHeader = "X-Player-Token"
+
+// NameHeader and KeyHeader are the HTTP headers a registered player
+// sends their name and account key in.
+const (
+	NameHeader = "X-Player-Name"
+	KeyHeader  = "X-Player-Key"
+)
+
 type TicTacToe interface {
 	ListGames() []string
-	CreateGame(id GameID, symbol Symbol) error
+	FindGames(filter GameFilter) (*GameList, error)
+	CreateGame(id GameID, symbol Symbol, opts GameOptions) (*JoinResponse, error)
 	JoinGame(id GameID) (*JoinResponse, error)
-	EndGame(id GameID) error
+	EndGame(id GameID, token string) error
 	GetGame(id GameID) (*GameState, error)
-	GameStream(gameID GameID, id string) (chan GameState, error)
-	DeleteStream(gameID GameID, id string) error
-	Move(id GameID, symbol Symbol, index int) (*GameState, error)
+	Subscribe(ctx context.Context, id GameID, buffer int, policy OverflowPolicy) (*Subscription, error)
+	SubscribeAfter(ctx context.Context, id GameID, seq uint64, buffer int, policy OverflowPolicy) (*Subscription, error)
+	Watch(ctx context.Context, id GameID, buffer int, policy OverflowPolicy) (*Subscription, error)
+	Move(id GameID, symbol Symbol, token string, index int) (*GameState, error)
+	Resign(id GameID, symbol Symbol, token string) (*GameState, error)
+	Chat(id GameID, symbol Symbol, token, text string) error
+	History(id GameID) (*GameHistory, error)
+	RequestTakeback(id GameID, symbol Symbol, token string) (*GameState, error)
+	AnswerTakeback(id GameID, symbol Symbol, token string, accept bool) (*GameState, error)
+	Matchmake(ctx context.Context, opts GameOptions) (*JoinResponse, error)
+	Register(name string) (*Account, error)
+	Profile(name string) (*Profile, error)
+	Leaderboard(limit int) []Profile
+	ClaimSeat(id GameID, symbol Symbol, token, name, key string) (*GameState, error)
+	Rematch(id GameID, symbol Symbol, token string) (*GameState, error)
+	SetMaxGames(max int)
+}
+
+// MoveRecord is one move in the history of a game.
+type MoveRecord struct {
+	Symbol Symbol    `json:"symbol"`
+	Index  int       `json:"index"`
+	Time   time.Time `json:"time"`
+	// Hash is the hash of the board after the move
+	Hash string `json:"hash"`
+}
+
+// GameHistory lists the moves of a game in the order they were played.
+type GameHistory struct {
+	ID    GameID       `json:"id"`
+	Shape Shape        `json:"shape"`
+	Moves []MoveRecord `json:"moves"`
 }
 
+// GameOptions configures a game when it is created. The zero
+// value is a Classic game between two people.
+type GameOptions struct {
+	// Shape is the board, the zero Shape means Classic
+	Shape
+
+	// AI seats a computer player of the given difficulty
+	// opposite the creator.
+	AI Difficulty `json:"ai"`
+
+	// BestOf makes the game a series that is over once a player
+	// has won more than half of BestOf games. Zero keeps score for
+	// as many rematches as the players like.
+	BestOf int `json:"best_of"`
+
+	// TimeControl puts the players on the clock, the zero value
+	// is untimed.
+	TimeControl TimeControl `json:"time_control"`
+}
+
+// NewTicTacToe keeps games in memory only.
 func NewTicTacToe() TicTacToe {
-	return &ttt{
-		games: map[GameID]*game{},
+	t, _ := NewTicTacToeFromStore(NewMemoryStore())
+	return t
+}
+
+// NewTicTacToeFromStore saves every change to a game in the store
+// and restores the games already in it, finished or not.
+func NewTicTacToeFromStore(store Store) (TicTacToe, error) {
+
+	accounts, err := newAccounts(store)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &ttt{
+		games:    map[GameID]*game{},
+		store:    store,
+		queue:    map[GameOptions][]*ticket{},
+		accounts: accounts,
 	}
+
+	ids, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		record, err := store.Load(id)
+		if err != nil {
+			return nil, err
+		}
+
+		game, err := restore(record, store, accounts)
+		if err != nil {
+			return nil, err
+		}
+		t.games[id] = game
+	}
+
+	return t, nil
 }
 
+// game is guarded by mu. States are published to the hub while
+// holding it so subscribers see them in order.
 type game struct {
-	board       []Symbol
-	streams     map[string]chan GameState
-	streamMutex sync.Mutex
-	turn        Symbol
-	playerX     bool
-	playerO     bool
+	mu     sync.Mutex
+	id     GameID
+	shape  Shape
+	board  []Symbol
+	hub    *hub
+	turn   Symbol
+	result Result
+	winner Symbol
+	status Status
+	// history is every move played, oldest first
+	history []MoveRecord
+	// takeback is the player who asked to take back their last move
+	takeback Symbol
+	// rematch is the player who asked to play again
+	rematch Symbol
+	// starter moved first in the current game of the series
+	starter Symbol
+	series  Series
+	// control is the time control, remaining the time each player
+	// had left when their clock last stopped
+	control   TimeControl
+	remaining map[Symbol]time.Duration
+	// started is when the clock of the player to move started, zero
+	// while it is stopped
+	started time.Time
+	// timer flags the player to move once their time is up, clockGen
+	// tells it whether the clock has been touched since it was armed
+	timer    *time.Timer
+	clockGen uint64
+	// tokens holds the secret of each taken seat
+	tokens map[Symbol]string
+	// players names the seats claimed by registered players, whose
+	// ratings change when a game between them finishes
+	players  map[Symbol]string
+	accounts *accounts
+	created  time.Time
+	// ended is set once the game is removed from the registry
+	ended bool
+	// ai plays aiSymbol after every move of the other seat
+	ai       Player
+	aiLevel  Difficulty
+	aiSymbol Symbol
+	store    Store
 }
 
 type ttt struct {
+	mu    sync.RWMutex
 	games map[GameID]*game
+	store Store
+
+	// queue holds the players waiting for a match by the options
+	// they asked for, longest waiting first
+	queueMu sync.Mutex
+	queue   map[GameOptions][]*ticket
+
+	accounts *accounts
+
+	// maxGames caps the games hosted at once, zero for no cap
+	maxGames int
+}
+
+// game looks up a game in the registry.
+func (t *ttt) game(id GameID) (*game, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	game, ok := t.games[id]
+	if !ok {
+		return nil, &GameNotFoundErr{}
+	}
+	return game, nil
+}
+
+// SetMaxGames caps how many games can be hosted at once. Games
+// restored from the store are kept even when there are more of them.
+// Zero lifts the cap.
+func (t *ttt) SetMaxGames(max int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.maxGames = max
 }
 
 func (t *ttt) ListGames() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
 	ids := []string{}
 	for i := range t.games {
 		ids = append(ids, string(i))
@@ -69,241 +300,653 @@ func (t *ttt) ListGames() []string {
 	return ids
 }
 
-func (t *ttt) CreateGame(id GameID, symbol Symbol) error {
+// validate fills in the Classic board when none is given and checks
+// the options.
+func (o GameOptions) validate() (GameOptions, error) {
+
+	if o.BestOf < 0 {
+		return o, &InvalidSeriesErr{}
+	}
+
+	if err := o.TimeControl.Valid(); err != nil {
+		return o, err
+	}
+
+	if o.Shape == (Shape{}) {
+		o.Shape = Classic
+	}
+	if err := o.Shape.Valid(); err != nil {
+		return o, err
+	}
+
+	return o, nil
+}
+
+func (t *ttt) CreateGame(id GameID, symbol Symbol, opts GameOptions) (*JoinResponse, error) {
+
+	opts, err := opts.validate()
+	if err != nil {
+		return nil, err
+	}
+	shape := opts.Shape
+
+	var ai Player
+	if opts.AI != NoAI {
+		player, err := NewPlayer(opts.AI)
+		if err != nil {
+			return nil, err
+		}
+		ai = player
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.games[id]; ok {
+		return nil, &GameExistsErr{}
+	}
 
-	if _, ok := t.games[GameID(id)]; ok {
-		return &GameExistsErr{}
+	if t.maxGames > 0 && len(t.games) >= t.maxGames {
+		return nil, &TooManyGamesErr{}
 	}
 
-	t.games[GameID(id)] = &game{
-		board:   make([]Symbol, 9),
-		streams: map[string]chan GameState{},
+	game := &game{
+		id:    id,
+		shape: shape,
+		board: make([]Symbol, shape.Cells()),
+		hub:   newHub(),
 		// player who created the game goes first
-		turn: symbol,
+		turn:    symbol,
+		starter: symbol,
+		created: time.Now(),
+		status:  Waiting,
+		series:  newSeries(opts.BestOf),
+		tokens:  map[Symbol]string{},
+		store:   t.store,
+
+		players:  map[Symbol]string{},
+		accounts: t.accounts,
+
+		control:   opts.TimeControl,
+		remaining: opts.TimeControl.fullTime(),
 	}
 
-	if symbol == X {
-		t.games[GameID(id)].playerX = true
-	} else {
-		t.games[GameID(id)].playerO = true
+	if ai != nil {
+		game.ai = ai
+		game.aiLevel = opts.AI
+		game.aiSymbol = opponent(symbol)
+		game.takeSeat(game.aiSymbol)
+		// nobody is subscribed yet to hear the game start
+		game.status = Playing
 	}
 
-	return nil
+	token := game.takeSeat(symbol)
+
+	if err := t.store.Create(game.record()); err != nil {
+		return nil, err
+	}
+	t.games[id] = game
+
+	// a computer opponent is seated already
+	game.startClock()
+
+	return &JoinResponse{
+		Symbol: symbol,
+		Token:  token,
+		State:  game.state(NoEvent),
+	}, nil
 }
 
+// JoinResponse is handed to a player when they take a seat. Token
+// must be presented on every Move and EndGame for that seat.
 type JoinResponse struct {
 	Symbol Symbol    `json:"symbol"`
+	Token  string    `json:"token"`
 	State  GameState `json:"state"`
 }
 
 func (t *ttt) JoinGame(id GameID) (*JoinResponse, error) {
 
-	game, ok := t.games[GameID(id)]
-	if !ok {
+	game, err := t.game(id)
+	if err != nil {
+		return nil, err
+	}
+
+	game.mu.Lock()
+	defer game.mu.Unlock()
+
+	if game.ended {
 		return nil, &GameNotFoundErr{}
 	}
 
 	sym := Empty
-	if !game.playerX {
-		game.playerX = true
+	if _, ok := game.tokens[X]; !ok {
 		sym = X
-	} else if !game.playerO {
-		game.playerO = true
+	} else if _, ok := game.tokens[O]; !ok {
 		sym = O
 	} else {
 		return nil, &TooManyPlayersErr{}
 	}
 
+	token := game.takeSeat(sym)
+
+	state := game.state(NoEvent)
+	if game.bothSeated() {
+		state = *game.become(Playing, JoinEvent)
+	} else {
+		game.save()
+	}
+
 	return &JoinResponse{
 		Symbol: sym,
-		State: GameState{
-			ID:    id,
-			Event: NoEvent,
-			Board: game.board,
-			Turn:  game.turn,
-		},
+		Token:  token,
+		State:  state,
 	}, nil
 }
 
-func (t *ttt) EndGame(id GameID) error {
+func (t *ttt) EndGame(id GameID, token string) error {
+
+	t.mu.Lock()
 
-	game, ok := t.games[GameID(id)]
+	game, ok := t.games[id]
 	if !ok {
+		t.mu.Unlock()
 		return &GameNotFoundErr{}
 	}
 
-	t.send(game, GameState{
-		Event: EndedEvent,
-	})
+	game.mu.Lock()
+	if !game.isSeated(X, token) && !game.isSeated(O, token) {
+		game.mu.Unlock()
+		t.mu.Unlock()
+		return &InvalidTokenErr{}
+	}
+	game.ended = true
+	// finished games keep their result, the rest are abandoned
+	if game.status.Over() {
+		game.hub.publish(game.state(EndedEvent))
+	} else {
+		game.become(Abandoned, EndedEvent)
+	}
+	game.hub.close()
+	game.mu.Unlock()
 
-	delete(t.games, GameID(id))
+	delete(t.games, id)
+	if err := t.store.Delete(id); err != nil {
+		log.Printf("WARN: could not delete game %s from the store: %v", id, err)
+	}
+	t.mu.Unlock()
 
 	return nil
 }
 
 func (t *ttt) GetGame(id GameID) (*GameState, error) {
 
-	game, ok := t.games[GameID(id)]
-	if !ok {
-		return nil, &GameNotFoundErr{}
+	game, err := t.game(id)
+	if err != nil {
+		return nil, err
 	}
 
-	return &GameState{
-		ID:    id,
-		Event: NoEvent,
-		Board: game.board,
-		Turn:  game.turn,
-	}, nil
+	game.mu.Lock()
+	defer game.mu.Unlock()
+
+	state := game.state(NoEvent)
+	return &state, nil
 }
 
-func (t *ttt) GameStream(gameID GameID, id string) (chan GameState, error) {
+// Subscribe follows the states published for a game until ctx is
+// done or the game ends. A zero buffer uses DefaultBuffer.
+func (t *ttt) Subscribe(ctx context.Context, id GameID, buffer int, policy OverflowPolicy) (*Subscription, error) {
 
-	game, ok := t.games[gameID]
-	if !ok {
-		return nil, &GameNotFoundErr{}
+	game, err := t.game(id)
+	if err != nil {
+		return nil, err
 	}
 
-	game.streamMutex.Lock()
-	defer game.streamMutex.Unlock()
+	return game.hub.subscribe(ctx, buffer, policy)
+}
+
+// SubscribeAfter is like Subscribe but first delivers the published
+// states after seq. Only the latest ReplayBuffer states are retained,
+// when older ones were missed the current state is delivered instead.
+func (t *ttt) SubscribeAfter(ctx context.Context, id GameID, seq uint64, buffer int, policy OverflowPolicy) (*Subscription, error) {
 
-	stream := make(chan GameState, 1)
-	game.streams[id] = stream
+	game, err := t.game(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// nothing is published between the snapshot and subscribing
+	game.mu.Lock()
+	defer game.mu.Unlock()
 
-	return stream, nil
+	return game.hub.subscribeAfter(ctx, seq, game.state(NoEvent), buffer, policy)
 }
 
-func (t *ttt) DeleteStream(gameID GameID, id string) error {
+// Watch is like Subscribe for spectators, who take no seat and are
+// counted in GameState.Spectators while subscribed.
+func (t *ttt) Watch(ctx context.Context, id GameID, buffer int, policy OverflowPolicy) (*Subscription, error) {
 
-	game, ok := t.games[gameID]
-	if !ok {
-		return &GameNotFoundErr{}
+	game, err := t.game(id)
+	if err != nil {
+		return nil, err
 	}
 
-	game.streamMutex.Lock()
-	defer game.streamMutex.Unlock()
-
-	delete(game.streams, id)
-	return nil
+	return game.hub.watch(ctx, buffer, policy)
 }
 
-func (t *ttt) Move(gameID GameID, symbol Symbol, index int) (*GameState, error) {
+func (t *ttt) Move(gameID GameID, symbol Symbol, token string, index int) (*GameState, error) {
 
-	game, ok := t.games[gameID]
-	if !ok {
-		return nil, &GameNotFoundErr{}
+	game, err := t.game(gameID)
+	if err != nil {
+		return nil, err
 	}
 
-	if game.turn != symbol {
-		return nil, &NotYourTurnErr{}
+	return game.move(symbol, token, index)
+}
+
+// move places the symbol on the board and publishes the resulting
+// state. In a game against the computer its reply is played right
+// away and the state after it is returned.
+func (g *game) move(symbol Symbol, token string, index int) (*GameState, error) {
+
+	g.mu.Lock()
+
+	if err := g.checkSeat(symbol, token); err != nil {
+		g.mu.Unlock()
+		return nil, err
 	}
 
-	if game.board[index] != Empty {
-		return nil, &IllegalMoveErr{}
+	if err := g.checkMove(symbol, index); err != nil {
+		g.mu.Unlock()
+		return nil, err
 	}
 
-	game.board[index] = symbol
+	state := g.place(symbol, index)
+	g.mu.Unlock()
 
-	if game.IsWon(symbol, index) {
+	return g.reply(state), nil
+}
 
-		state := &GameState{
-			Event:  WinEvent,
-			Winner: symbol,
-			Board:  game.board,
+// reply plays the computer's move when it is its turn in state and
+// returns the state after it. The computer thinks from a copy of the
+// game without holding the lock, so the game can be read meanwhile.
+// Should the board change before it is done, such as by a takeback,
+// it thinks again from the game as it is now. The game lock must not
+// be held.
+func (g *game) reply(state *GameState) *GameState {
+
+	// ai and aiSymbol never change once the game is created
+	for g.ai != nil && state.Status == Playing && state.Turn == g.aiSymbol {
+
+		index := g.ai.Move(*state, g.aiSymbol)
+
+		g.mu.Lock()
+		if !g.ended && g.status == Playing && g.turn == g.aiSymbol && Hash(g.board) == Hash(state.Board) {
+			state = g.place(g.aiSymbol, index)
+			g.mu.Unlock()
+			return state
 		}
+		current := g.state(NoEvent)
+		g.mu.Unlock()
 
-		t.send(game, *state)
-		return state, nil
+		state = &current
 	}
 
-	if game.turn == X {
-		game.turn = O
-	} else if game.turn == O {
-		game.turn = X
+	return state
+}
+
+// place puts the symbol on the board, passes the turn or settles
+// the result and publishes the new state.
+func (g *game) place(symbol Symbol, index int) *GameState {
+
+	g.punchClock(symbol)
+
+	g.board[index] = symbol
+	// playing on withdraws or overrules any takeback request
+	g.takeback = Empty
+	g.history = append(g.history, MoveRecord{
+		Symbol: symbol,
+		Index:  index,
+		Time:   time.Now(),
+		Hash:   Hash(g.board),
+	})
+
+	// nobody can move once the game has a result
+	if g.IsWon(symbol, index) {
+		return g.finish(WonStatus, symbol, WinEvent)
 	}
 
-	state := &GameState{
-		Event: MoveEvent,
-		Turn:  game.turn,
-		Board: game.board,
+	if g.IsFull() {
+		return g.finish(DrawnStatus, Empty, DrawEvent)
 	}
-	t.send(game, *state)
 
-	return state, nil
+	g.turn = opponent(g.turn)
+	g.startClock()
+
+	return g.publish(MoveEvent)
 }
 
-func (g *game) IsWon(symbol Symbol, i int) bool {
+func (t *ttt) Resign(id GameID, symbol Symbol, token string) (*GameState, error) {
 
-	b := g.board
+	game, err := t.game(id)
+	if err != nil {
+		return nil, err
+	}
 
-	// if center is last symbol
-	if b[4] == symbol {
+	game.mu.Lock()
+	defer game.mu.Unlock()
 
-		// middle horizontal
-		if b[3] == b[4] && b[4] == b[5] {
-			return true
-		}
+	if err := game.checkSeat(symbol, token); err != nil {
+		return nil, err
+	}
 
-		// middle vertical
-		if b[1] == b[4] && b[4] == b[7] {
-			return true
-		}
+	if err := game.checkPlaying(); err != nil {
+		return nil, err
+	}
 
-		// diag
-		if b[0] == b[4] && b[4] == b[8] {
-			return true
-		}
+	return game.finish(Resigned, opponent(symbol), ResignEvent), nil
+}
 
-		// anti diag
-		if b[2] == b[4] && b[4] == b[6] {
-			return true
-		}
+func (t *ttt) Chat(id GameID, symbol Symbol, token, text string) error {
+
+	game, err := t.game(id)
+	if err != nil {
+		return err
 	}
 
-	// if top left is last symbol
-	if b[0] == symbol {
+	game.mu.Lock()
+	defer game.mu.Unlock()
 
-		// top horizontal
-		if b[0] == b[1] && b[1] == b[2] {
-			return true
-		}
+	if err := game.checkSeat(symbol, token); err != nil {
+		return err
+	}
 
-		// left vertical
-		if b[0] == b[3] && b[3] == b[6] {
-			return true
-		}
+	state := game.state(ChatEvent)
+	state.Chat = &Chat{
+		From: symbol,
+		Text: text,
 	}
+	game.hub.publish(state)
+	game.save()
 
-	// if bottom right is last symbol
-	if b[8] == symbol {
+	return nil
+}
 
-		// bottom horizontal
-		if b[6] == b[7] && b[7] == b[8] {
-			return true
-		}
+func (t *ttt) History(id GameID) (*GameHistory, error) {
 
-		// right vertical
-		if b[2] == b[5] && b[5] == b[8] {
-			return true
+	game, err := t.game(id)
+	if err != nil {
+		return nil, err
+	}
+
+	game.mu.Lock()
+	defer game.mu.Unlock()
+
+	moves := make([]MoveRecord, len(game.history))
+	copy(moves, game.history)
+
+	return &GameHistory{
+		ID:    game.id,
+		Shape: game.shape,
+		Moves: moves,
+	}, nil
+}
+
+func opponent(symbol Symbol) Symbol {
+	if symbol == X {
+		return O
+	}
+	return X
+}
+
+// publish sends a snapshot of the game to its subscribers and
+// saves the game.
+func (g *game) publish(event EventType) *GameState {
+	state := g.hub.publish(g.state(event))
+	g.save()
+	return &state
+}
+
+// save writes the game to the store. The whole game is written every
+// time, so a failed save is caught up by the next one and the game
+// carries on in memory meanwhile.
+func (g *game) save() {
+	if err := g.store.Save(g.record()); err != nil {
+		log.Printf("WARN: could not save game %s: %v", g.id, err)
+	}
+}
+
+func (g *game) record() *GameRecord {
+	board := make([]Symbol, len(g.board))
+	copy(board, g.board)
+
+	tokens := map[Symbol]string{}
+	for k, v := range g.tokens {
+		tokens[k] = v
+	}
+
+	history := make([]MoveRecord, len(g.history))
+	copy(history, g.history)
+
+	remaining := map[Symbol]time.Duration{}
+	for k, v := range g.remaining {
+		remaining[k] = v
+	}
+
+	players := map[Symbol]string{}
+	for k, v := range g.players {
+		players[k] = v
+	}
+
+	return &GameRecord{
+		ID:        g.id,
+		Shape:     g.shape,
+		Board:     board,
+		Turn:      g.turn,
+		Result:    g.result,
+		Winner:    g.winner,
+		Status:    g.status,
+		Tokens:    tokens,
+		Players:   players,
+		History:   history,
+		Takeback:  g.takeback,
+		Rematch:   g.rematch,
+		Starter:   g.starter,
+		Series:    g.series.copy(),
+		Created:   g.created,
+		Control:   g.control,
+		Remaining: remaining,
+		AI:        g.aiLevel,
+		AISymbol:  g.aiSymbol,
+		Seq:       g.hub.last(),
+	}
+}
+
+// restore rebuilds a game saved by record.
+func restore(record *GameRecord, store Store, accounts *accounts) (*game, error) {
+
+	if err := record.Shape.Valid(); err != nil {
+		return nil, err
+	}
+	if len(record.Board) != record.Shape.Cells() {
+		return nil, &InvalidBoardErr{}
+	}
+
+	g := &game{
+		id:        record.ID,
+		shape:     record.Shape,
+		board:     record.Board,
+		hub:       newHub(),
+		turn:      record.Turn,
+		result:    record.Result,
+		winner:    record.Winner,
+		status:    record.Status,
+		tokens:    record.Tokens,
+		history:   record.History,
+		takeback:  record.Takeback,
+		rematch:   record.Rematch,
+		starter:   record.Starter,
+		series:    record.Series.copy(),
+		created:   record.Created,
+		control:   record.Control,
+		remaining: record.Remaining,
+		aiLevel:   record.AI,
+		aiSymbol:  record.AISymbol,
+		store:     store,
+		players:   record.Players,
+		accounts:  accounts,
+	}
+	g.hub.seq = record.Seq
+
+	if g.tokens == nil {
+		g.tokens = map[Symbol]string{}
+	}
+	if g.players == nil {
+		g.players = map[Symbol]string{}
+	}
+	if g.remaining == nil {
+		g.remaining = g.control.fullTime()
+	}
+	if g.status == "" {
+		g.status = statusOf(record)
+	}
+
+	if record.AI != NoAI {
+		ai, err := NewPlayer(record.AI)
+		if err != nil {
+			return nil, err
 		}
+		g.ai = ai
+	}
+
+	// the time the server was down is not charged to anyone
+	g.startClock()
+
+	return g, nil
+}
+
+// state snapshots the game. The board is copied so the
+// snapshot can be used after the game lock is released.
+func (g *game) state(event EventType) GameState {
+	board := make([]Symbol, len(g.board))
+	copy(board, g.board)
+
+	return GameState{
+		Shape:    g.shape,
+		ID:       g.id,
+		Event:    event,
+		Board:    board,
+		Turn:     g.turn,
+		Winner:   g.winner,
+		Result:   g.result,
+		Status:   g.status,
+		Takeback: g.takeback,
+		Rematch:  g.rematch,
+		Series:   g.series.copy(),
+		Clock:    g.clock(),
+		Seq:      g.hub.last(),
+		Players:  g.playerNames(),
+
+		Spectators: g.hub.spectators(),
 	}
+}
 
-	return false
+// playerNames copies the claimed seats, nil when there are none.
+func (g *game) playerNames() map[Symbol]string {
+	if len(g.players) == 0 {
+		return nil
+	}
+	players := map[Symbol]string{}
+	for k, v := range g.players {
+		players[k] = v
+	}
+	return players
 }
 
-func (t *ttt) send(game *game, state GameState) {
-	for i := range game.streams {
-		game.streams[i] <- state
+// takeSeat issues a new secret token for the symbol's seat.
+func (g *game) takeSeat(symbol Symbol) string {
+	token := uuid.NewV4().String()
+	g.tokens[symbol] = token
+	return token
+}
+
+// checkSeat makes sure the game is still on and token is the
+// secret for the symbol's seat. The game lock must be held.
+func (g *game) checkSeat(symbol Symbol, token string) error {
+	if g.ended {
+		return &GameNotFoundErr{}
+	}
+	if !symbol.Valid() {
+		return &InvalidSymbolErr{}
 	}
+	if !g.isSeated(symbol, token) {
+		return &InvalidTokenErr{}
+	}
+	return nil
+}
+
+// isSeated reports whether token is the secret for the symbol's seat.
+func (g *game) isSeated(symbol Symbol, token string) bool {
+	seat, ok := g.tokens[symbol]
+	if !ok || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(seat), []byte(token)) == 1
 }
 
+// IsWon reports whether the symbol just played at i wins the game.
+func (g *game) IsWon(symbol Symbol, i int) bool {
+	return g.shape.IsWonAt(g.board, symbol, i)
+}
+
+// IsFull reports whether every cell of the board is taken.
+func (g *game) IsFull() bool {
+	return isFull(g.board)
+}
+
+func isFull(b []Symbol) bool {
+	for _, s := range b {
+		if s == Empty {
+			return false
+		}
+	}
+	return true
+}
+
+// Hash encodes a board as one character per cell, - for empty, 1 for
+// X and 2 for O. Long polls compare it with the hash of the game's
+// board, so clients must compute it with this function.
 func Hash(b []Symbol) string {
 	h := ""
 	for _, v := range b {
-		if v == "" {
+		switch v {
+		case Empty:
 			h += "-"
-		} else {
+		case X:
+			h += "1"
+		case O:
+			h += "2"
+		default:
 			h += string(v)
 		}
 	}
 	return h
 }
+
+// ParseHash decodes a board encoded by Hash.
+func ParseHash(h string) ([]Symbol, error) {
+	board := make([]Symbol, len(h))
+	for i, c := range h {
+		switch c {
+		case '-':
+			board[i] = Empty
+		case '1':
+			board[i] = X
+		case '2':
+			board[i] = O
+		default:
+			return nil, &InvalidPositionErr{}
+		}
+	}
+	return board, nil
+}
@@ -1,6 +1,7 @@
 package tictactoe
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -12,20 +13,29 @@ func TestTicTacToe(t *testing.T) {
 
 	ttt := NewTicTacToe()
 
-	ttt.CreateGame("sucker", X)
+	x, err := ttt.CreateGame("sucker", X, GameOptions{})
+	require.NoError(t, err)
+
+	o, err := ttt.JoinGame("sucker")
+	require.NoError(t, err)
+	require.Equal(t, O, o.Symbol)
 
 	ids := ttt.ListGames()
 	fmt.Println(ids)
 
-	stream, err := ttt.GameStream("sucker", "asdf")
+	sub, err := ttt.Subscribe(context.Background(), "sucker", 0, DropOldest)
 	require.NoError(t, err)
+	defer sub.Unsubscribe()
 
 	ticker := time.NewTicker(500 * time.Millisecond)
 
 	go func() {
 		for {
 			select {
-			case data := <-stream:
+			case data, ok := <-sub.Events():
+				if !ok {
+					return
+				}
 				fmt.Println("Received game data", data)
 			case t := <-ticker.C:
 				fmt.Println("Tick at", t)
@@ -33,21 +43,180 @@ func TestTicTacToe(t *testing.T) {
 		}
 	}()
 
-	_, err = ttt.Move("sucker", "O", 0)
+	_, err = ttt.Move("sucker", "X", x.Token, 0)
+	require.NoError(t, err)
+
+	_, err = ttt.Move("sucker", "O", o.Token, 6)
+	require.NoError(t, err)
+
+	_, err = ttt.Move("sucker", "X", x.Token, 1)
+	require.NoError(t, err)
+
+	_, err = ttt.Move("sucker", "O", o.Token, 7)
+	require.NoError(t, err)
+
+	state, err := ttt.Move("sucker", "X", x.Token, 2)
+	require.NoError(t, err)
+	require.Equal(t, X, state.Winner)
+	require.Equal(t, Empty, state.Turn)
+
+	_, err = ttt.Move("sucker", "O", o.Token, 8)
+	require.IsType(t, &GameOverErr{}, err)
+
+}
+
+func TestTokens(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	x, err := ttt.CreateGame("tokens", X, GameOptions{})
+	require.NoError(t, err)
+	require.NotEmpty(t, x.Token)
+
+	o, err := ttt.JoinGame("tokens")
+	require.NoError(t, err)
+	require.NotEqual(t, x.Token, o.Token)
+
+	_, err = ttt.Move("tokens", X, o.Token, 0)
+	require.IsType(t, &InvalidTokenErr{}, err)
+
+	_, err = ttt.Move("tokens", X, "", 0)
+	require.IsType(t, &InvalidTokenErr{}, err)
+
+	_, err = ttt.Move("tokens", X, x.Token, 0)
+	require.NoError(t, err)
+
+	err = ttt.EndGame("tokens", "not-a-token")
+	require.IsType(t, &InvalidTokenErr{}, err)
+
+	require.NoError(t, ttt.EndGame("tokens", o.Token))
+}
+
+func TestMaxGames(t *testing.T) {
+
+	ttt := NewTicTacToe()
+	ttt.SetMaxGames(2)
+
+	one, err := ttt.CreateGame("one", X, GameOptions{})
+	require.NoError(t, err)
+	_, err = ttt.CreateGame("two", X, GameOptions{})
+	require.NoError(t, err)
+
+	_, err = ttt.CreateGame("three", X, GameOptions{})
+	require.IsType(t, &TooManyGamesErr{}, err)
+
+	// ending a game frees its place
+	require.NoError(t, ttt.EndGame("one", one.Token))
+	_, err = ttt.CreateGame("three", X, GameOptions{})
+	require.NoError(t, err)
+
+	ttt.SetMaxGames(0)
+	_, err = ttt.CreateGame("four", X, GameOptions{})
+	require.NoError(t, err)
+}
+
+func TestDraw(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	x, err := ttt.CreateGame("draw", X, GameOptions{})
+	require.NoError(t, err)
+
+	o, err := ttt.JoinGame("draw")
+	require.NoError(t, err)
+
+	// X O X
+	// X O O
+	// O X X
+	moves := []struct {
+		symbol Symbol
+		token  string
+		index  int
+	}{
+		{X, x.Token, 0}, {O, o.Token, 1}, {X, x.Token, 2},
+		{O, o.Token, 4}, {X, x.Token, 3}, {O, o.Token, 5},
+		{X, x.Token, 7}, {O, o.Token, 6}, {X, x.Token, 8},
+	}
+
+	var state *GameState
+	for _, m := range moves {
+		state, err = ttt.Move("draw", m.symbol, m.token, m.index)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, DrawEvent, state.Event)
+	require.Equal(t, Drawn, state.Result)
+	require.Equal(t, Empty, state.Winner)
+
+	state, err = ttt.GetGame("draw")
+	require.NoError(t, err)
+	require.Equal(t, Drawn, state.Result)
+	require.Equal(t, Empty, state.Turn)
+}
+
+func TestResignAndChat(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	x, err := ttt.CreateGame("resign", X, GameOptions{})
+	require.NoError(t, err)
+
+	o, err := ttt.JoinGame("resign")
+	require.NoError(t, err)
+
+	sub, err := ttt.Subscribe(context.Background(), "resign", 0, DropOldest)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	require.NoError(t, ttt.Chat("resign", O, o.Token, "good luck"))
+	require.IsType(t, &InvalidTokenErr{}, ttt.Chat("resign", O, x.Token, "impostor"))
+
+	state := <-sub.Events()
+	require.Equal(t, ChatEvent, state.Event)
+	require.Equal(t, &Chat{From: O, Text: "good luck"}, state.Chat)
+
+	state2, err := ttt.Resign("resign", X, x.Token)
 	require.NoError(t, err)
+	require.Equal(t, ResignEvent, state2.Event)
+	require.Equal(t, Won, state2.Result)
+	require.Equal(t, O, state2.Winner)
 
-	_, err = ttt.Move("sucker", "X", 6)
+	_, err = ttt.Resign("resign", O, o.Token)
+	require.IsType(t, &GameOverErr{}, err)
+}
+
+func TestHistory(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	x, err := ttt.CreateGame("history", X, GameOptions{AI: Medium})
 	require.NoError(t, err)
 
-	_, err = ttt.Move("sucker", "O", 1)
+	history, err := ttt.History("history")
 	require.NoError(t, err)
+	require.Empty(t, history.Moves)
+	require.Equal(t, Classic, history.Shape)
 
-	_, err = ttt.Move("sucker", "X", 7)
+	start := time.Now()
+
+	_, err = ttt.Move("history", X, x.Token, 0)
 	require.NoError(t, err)
 
-	_, err = ttt.Move("sucker", "O", 2)
+	history, err = ttt.History("history")
 	require.NoError(t, err)
+	require.Len(t, history.Moves, 2)
+
+	require.Equal(t, X, history.Moves[0].Symbol)
+	require.Equal(t, 0, history.Moves[0].Index)
+	require.Equal(t, "1--------", history.Moves[0].Hash)
+	require.False(t, history.Moves[0].Time.Before(start))
 
+	require.Equal(t, O, history.Moves[1].Symbol)
+	require.Equal(t, 4, history.Moves[1].Index)
+	require.Equal(t, "1---2----", history.Moves[1].Hash)
+
+	_, err = ttt.History("missing")
+	require.IsType(t, &GameNotFoundErr{}, err)
 }
 
 func TestHash(t *testing.T) {
@@ -56,4 +225,8 @@ func TestHash(t *testing.T) {
 
 	b := []Symbol{"X", "O", "X", "-", "O", "-", "X", "X", "X"}
 	require.Equal(t, Hash(b), "121-2-111")
+
+	// X and O are told apart whatever else is on the board
+	require.NotEqual(t, Hash([]Symbol{X, Empty}), Hash([]Symbol{O, Empty}))
+	require.Equal(t, "--", Hash([]Symbol{Empty, Empty}))
 }
@@ -0,0 +1,229 @@
+package tictactoe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// GameOutcome is how a game between two strategies ended.
+type GameOutcome struct {
+	// Winner is Empty for a draw
+	Winner Symbol
+	// Forfeit is set when the loser played a move the game refused
+	Forfeit bool
+}
+
+// Arena plays games between strategies, in process or against a
+// server.
+type Arena interface {
+	// Play has x and o play a game to its end, x moving first.
+	Play(ctx context.Context, x, o Strategy) (GameOutcome, error)
+}
+
+// MoveFunc plays the cell at index for symbol and returns the state
+// of the game after the move.
+type MoveFunc func(symbol Symbol, index int) (*GameState, error)
+
+// PlayOut has x and o take turns from state, playing each move with
+// move, until the game is over. A strategy whose move is refused as
+// illegal or off the board forfeits the game.
+func PlayOut(ctx context.Context, state *GameState, x, o Strategy, move MoveFunc) (GameOutcome, error) {
+
+	players := map[Symbol]Strategy{X: x, O: o}
+
+	for !state.Status.Over() {
+
+		if err := ctx.Err(); err != nil {
+			return GameOutcome{}, err
+		}
+
+		symbol := state.Turn
+		next, err := move(symbol, players[symbol].Move(*state, symbol))
+
+		var illegal *IllegalMoveErr
+		var offBoard *OffBoardErr
+		if errors.As(err, &illegal) || errors.As(err, &offBoard) {
+			return GameOutcome{Winner: opponent(symbol), Forfeit: true}, nil
+		}
+		if err != nil {
+			return GameOutcome{}, err
+		}
+
+		state = next
+	}
+
+	return GameOutcome{Winner: state.Winner}, nil
+}
+
+// NewLocalArena plays games in process, on a TicTacToe of its own so
+// the strategies play by the same rules as on the server.
+func NewLocalArena(opts GameOptions) Arena {
+	return &localArena{
+		ttt:  NewTicTacToe(),
+		opts: opts,
+	}
+}
+
+type localArena struct {
+	ttt  TicTacToe
+	opts GameOptions
+}
+
+func (a *localArena) Play(ctx context.Context, x, o Strategy) (GameOutcome, error) {
+
+	id := GameID("tournament-" + uuid.NewV4().String()[:8])
+
+	xSeat, err := a.ttt.CreateGame(id, X, a.opts)
+	if err != nil {
+		return GameOutcome{}, err
+	}
+	defer a.ttt.EndGame(id, xSeat.Token)
+
+	oSeat, err := a.ttt.JoinGame(id)
+	if err != nil {
+		return GameOutcome{}, err
+	}
+
+	tokens := map[Symbol]string{X: xSeat.Token, O: oSeat.Token}
+
+	return PlayOut(ctx, &oSeat.State, x, o, func(symbol Symbol, index int) (*GameState, error) {
+		return a.ttt.Move(id, symbol, tokens[symbol], index)
+	})
+}
+
+// Record counts the games a strategy won, drew and lost.
+type Record struct {
+	Wins   int `json:"wins"`
+	Draws  int `json:"draws"`
+	Losses int `json:"losses"`
+	// Forfeits counts the losses by a refused move
+	Forfeits int `json:"forfeits"`
+}
+
+// Played is the number of games in the record.
+func (r Record) Played() int {
+	return r.Wins + r.Draws + r.Losses
+}
+
+// Standing is how a strategy did over a tournament.
+type Standing struct {
+	Record
+	Name string `json:"name"`
+	// Rating is an Elo estimate of the strategy's strength, starting
+	// from InitialRating and updated after each game in turn
+	Rating int `json:"rating"`
+}
+
+// TournamentResult is the outcome of a tournament.
+type TournamentResult struct {
+	// Standings are ordered by rating, the strongest first
+	Standings []Standing `json:"standings"`
+	// HeadToHead is the record of each strategy against each other,
+	// keyed by the strategy's name and then its opponent's
+	HeadToHead map[string]map[string]Record `json:"head_to_head"`
+}
+
+// RunTournament plays the registered strategies with the names
+// against each other round robin. Every pair plays rounds games with
+// each strategy moving first, on a new instance of each strategy
+// every game.
+func RunTournament(ctx context.Context, arena Arena, names []string, rounds int) (*TournamentResult, error) {
+
+	if len(names) < 2 {
+		return nil, &TooFewStrategiesErr{}
+	}
+	if rounds < 1 {
+		return nil, &NoRoundsErr{}
+	}
+
+	standings := map[string]*Standing{}
+	result := &TournamentResult{
+		HeadToHead: map[string]map[string]Record{},
+	}
+
+	for _, name := range names {
+		if _, ok := standings[name]; ok {
+			return nil, fmt.Errorf("%w: %s", &DuplicateStrategyErr{}, name)
+		}
+		if _, err := NewStrategy(name); err != nil {
+			return nil, err
+		}
+		standings[name] = &Standing{Name: name, Rating: InitialRating}
+		result.HeadToHead[name] = map[string]Record{}
+	}
+
+	for i, a := range names {
+		for _, b := range names[i+1:] {
+			for round := 0; round < rounds; round++ {
+				for _, pair := range [][2]string{{a, b}, {b, a}} {
+
+					x, _ := NewStrategy(pair[0])
+					o, _ := NewStrategy(pair[1])
+
+					outcome, err := arena.Play(ctx, x, o)
+					if err != nil {
+						return nil, err
+					}
+
+					result.record(standings, pair[0], pair[1], outcome)
+				}
+			}
+		}
+	}
+
+	for _, name := range names {
+		result.Standings = append(result.Standings, *standings[name])
+	}
+	sort.SliceStable(result.Standings, func(i, j int) bool {
+		return result.Standings[i].Rating > result.Standings[j].Rating
+	})
+
+	return result, nil
+}
+
+// record counts a game between the strategies named x and o.
+func (r *TournamentResult) record(standings map[string]*Standing, x, o string, outcome GameOutcome) {
+
+	sx, so := standings[x], standings[o]
+	hx, ho := r.HeadToHead[x][o], r.HeadToHead[o][x]
+
+	// the score of X, one for a win and a half for a draw
+	score := 0.5
+	switch outcome.Winner {
+	case Empty:
+		sx.Draws++
+		so.Draws++
+		hx.Draws++
+		ho.Draws++
+	case X:
+		score = 1
+		sx.Wins++
+		so.Losses++
+		hx.Wins++
+		ho.Losses++
+		if outcome.Forfeit {
+			so.Forfeits++
+			ho.Forfeits++
+		}
+	default:
+		score = 0
+		sx.Losses++
+		so.Wins++
+		hx.Losses++
+		ho.Wins++
+		if outcome.Forfeit {
+			sx.Forfeits++
+			hx.Forfeits++
+		}
+	}
+
+	r.HeadToHead[x][o], r.HeadToHead[o][x] = hx, ho
+
+	change := ratingChange(sx.Rating, so.Rating, score)
+	sx.Rating += change
+	so.Rating -= change
+}
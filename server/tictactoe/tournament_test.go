@@ -0,0 +1,103 @@
+package tictactoe
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTournament(t *testing.T) {
+
+	ctx := context.Background()
+	arena := NewLocalArena(GameOptions{})
+
+	result, err := RunTournament(ctx, arena, []string{"random", "perfect", "heuristic"}, 5)
+	require.NoError(t, err)
+
+	// every pair plays 5 games each way
+	require.Len(t, result.Standings, 3)
+	for _, s := range result.Standings {
+		require.Equal(t, 20, s.Played())
+	}
+
+	standings := map[string]Standing{}
+	for _, s := range result.Standings {
+		standings[s.Name] = s
+	}
+
+	// perfect play never loses, so it is rated above random play
+	require.Zero(t, standings["perfect"].Losses)
+	require.Zero(t, result.HeadToHead["random"]["perfect"].Wins)
+	require.True(t, standings["perfect"].Rating > standings["random"].Rating)
+
+	// strongest first
+	for i := 1; i < len(result.Standings); i++ {
+		require.True(t, result.Standings[i-1].Rating >= result.Standings[i].Rating)
+	}
+
+	// head to head records mirror each other
+	for name, opponents := range result.HeadToHead {
+		for opponent, record := range opponents {
+			mirror := result.HeadToHead[opponent][name]
+			require.Equal(t, record.Wins, mirror.Losses)
+			require.Equal(t, record.Draws, mirror.Draws)
+			require.Equal(t, 10, record.Played())
+		}
+	}
+
+	// ratings only move between the entrants
+	total := 0
+	for _, s := range result.Standings {
+		total += s.Rating
+	}
+	require.Equal(t, 3*InitialRating, total)
+}
+
+func TestTournamentForfeit(t *testing.T) {
+
+	// always plays the top left cell, which is refused once taken
+	RegisterStrategy("stubborn", func() Strategy {
+		return StrategyFunc(func(GameState, Symbol) int {
+			return 0
+		})
+	})
+	defer func() {
+		strategiesMu.Lock()
+		delete(strategies, "stubborn")
+		strategiesMu.Unlock()
+	}()
+
+	result, err := RunTournament(context.Background(), NewLocalArena(GameOptions{}), []string{"stubborn", "perfect"}, 1)
+	require.NoError(t, err)
+
+	stubborn := result.HeadToHead["stubborn"]["perfect"]
+	require.Equal(t, Record{Losses: 2, Forfeits: 2}, stubborn)
+	require.Equal(t, Record{Wins: 2}, result.HeadToHead["perfect"]["stubborn"])
+}
+
+func TestTournamentEntrants(t *testing.T) {
+
+	ctx := context.Background()
+	arena := NewLocalArena(GameOptions{})
+
+	_, err := RunTournament(ctx, arena, []string{"perfect"}, 1)
+	require.IsType(t, &TooFewStrategiesErr{}, err)
+
+	_, err = RunTournament(ctx, arena, []string{"perfect", "perfect"}, 1)
+	var duplicate *DuplicateStrategyErr
+	require.True(t, errors.As(err, &duplicate), "got %v", err)
+	require.EqualError(t, err, "Strategy entered twice: perfect")
+
+	_, err = RunTournament(ctx, arena, []string{"perfect", "clairvoyant"}, 1)
+	require.IsType(t, &UnknownStrategyErr{}, err)
+
+	_, err = RunTournament(ctx, arena, []string{"perfect", "random"}, 0)
+	require.IsType(t, &NoRoundsErr{}, err)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = RunTournament(cancelled, arena, []string{"perfect", "random"}, 1)
+	require.Equal(t, context.Canceled, err)
+}
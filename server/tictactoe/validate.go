@@ -0,0 +1,41 @@
+package tictactoe
+
+// Valid reports whether the symbol can hold a seat.
+func (s Symbol) Valid() bool {
+	return s == X || s == O
+}
+
+// bothSeated reports whether both seats have been taken.
+func (g *game) bothSeated() bool {
+	return len(g.tokens) == 2
+}
+
+// checkMove makes sure the seated symbol may play at index. Each
+// reason a move is refused has its own error so clients can tell
+// them apart. The game lock must be held.
+func (g *game) checkMove(symbol Symbol, index int) error {
+
+	if err := g.checkPlaying(); err != nil {
+		return err
+	}
+
+	if g.turn != symbol {
+		return &NotYourTurnErr{}
+	}
+
+	// the timer may not have had the lock yet
+	if g.outOfTime() {
+		g.flag()
+		return &GameOverErr{}
+	}
+
+	if !g.shape.Contains(index) {
+		return &OffBoardErr{}
+	}
+
+	if g.board[index] != Empty {
+		return &IllegalMoveErr{}
+	}
+
+	return nil
+}
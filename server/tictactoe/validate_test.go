@@ -0,0 +1,104 @@
+package tictactoe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveValidation(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	// waiting for O
+	waiting, err := ttt.CreateGame("waiting", X, GameOptions{})
+	require.NoError(t, err)
+
+	// X has played the centre and it is O's turn
+	playing, err := ttt.CreateGame("playing", X, GameOptions{})
+	require.NoError(t, err)
+	o, err := ttt.JoinGame("playing")
+	require.NoError(t, err)
+	_, err = ttt.Move("playing", X, playing.Token, 4)
+	require.NoError(t, err)
+
+	// X has won
+	won, err := ttt.CreateGame("won", X, GameOptions{})
+	require.NoError(t, err)
+	wonO, err := ttt.JoinGame("won")
+	require.NoError(t, err)
+	seats := []*JoinResponse{won, wonO}
+	for i, index := range []int{0, 3, 1, 4, 2} {
+		seat := seats[i%2]
+		_, err = ttt.Move("won", seat.Symbol, seat.Token, index)
+		require.NoError(t, err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		id     GameID
+		symbol Symbol
+		token  string
+		index  int
+		err    error
+	}{
+		{"not started", "waiting", X, waiting.Token, 0, &GameNotStartedErr{}},
+		{"game over", "won", O, wonO.Token, 8, &GameOverErr{}},
+		{"unknown game", "nope", O, o.Token, 0, &GameNotFoundErr{}},
+		{"unknown symbol", "playing", "Z", o.Token, 0, &InvalidSymbolErr{}},
+		{"lowercase symbol", "playing", "o", o.Token, 0, &InvalidSymbolErr{}},
+		{"empty symbol", "playing", Empty, o.Token, 0, &InvalidSymbolErr{}},
+		{"wrong token", "playing", O, playing.Token, 0, &InvalidTokenErr{}},
+		{"not your turn", "playing", X, playing.Token, 0, &NotYourTurnErr{}},
+		{"negative index", "playing", O, o.Token, -1, &OffBoardErr{}},
+		{"past the end", "playing", O, o.Token, 9, &OffBoardErr{}},
+		{"far off", "playing", O, o.Token, 42, &OffBoardErr{}},
+		{"taken", "playing", O, o.Token, 4, &IllegalMoveErr{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			state, err := ttt.Move(tc.id, tc.symbol, tc.token, tc.index)
+			require.Nil(t, state)
+			require.IsType(t, tc.err, err)
+		})
+	}
+
+	// nothing was played by the refused moves
+	state, err := ttt.Move("playing", O, o.Token, 0)
+	require.NoError(t, err)
+	require.Equal(t, []Symbol{O, Empty, Empty, Empty, X, Empty, Empty, Empty, Empty}, state.Board)
+}
+
+func TestSeatValidation(t *testing.T) {
+
+	ttt := NewTicTacToe()
+
+	x, err := ttt.CreateGame("seats", X, GameOptions{})
+	require.NoError(t, err)
+	_, err = ttt.JoinGame("seats")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name string
+		call func() error
+	}{
+		{"resign", func() error {
+			_, err := ttt.Resign("seats", "Z", x.Token)
+			return err
+		}},
+		{"takeback", func() error {
+			_, err := ttt.RequestTakeback("seats", "Z", x.Token)
+			return err
+		}},
+		{"rematch", func() error {
+			_, err := ttt.Rematch("seats", "Z", x.Token)
+			return err
+		}},
+		{"chat", func() error {
+			return ttt.Chat("seats", "Z", x.Token, "hi")
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.IsType(t, &InvalidSymbolErr{}, tc.call())
+		})
+	}
+}
@@ -0,0 +1,152 @@
+// Command tournament plays the registered strategies against each
+// other round robin and prints how they did.
+//
+//	tournament -rounds 20
+//	tournament -server http://localhost:8080 -strategies perfect,heuristic
+//
+// Games are played in process unless a server is given, in which case
+// every game is played over its API.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/svolpe43/ttt/client"
+	"github.com/svolpe43/ttt/server/tictactoe"
+)
+
+func main() {
+
+	fs := flag.NewFlagSet("tournament", flag.ContinueOnError)
+	server := fs.String("server", "", "URL of the server to play on, games are played in process when empty")
+	names := fs.String("strategies", strings.Join(tictactoe.StrategyNames(), ","), "comma separated strategies to enter")
+	rounds := fs.Int("rounds", 10, "games each pair plays with each strategy moving first")
+	width := fs.Int("width", tictactoe.Classic.Width, "columns of the board")
+	height := fs.Int("height", tictactoe.Classic.Height, "rows of the board")
+	win := fs.Int("win", tictactoe.Classic.WinLength, "symbols in a row that win")
+	timeout := fs.Duration("timeout", client.DefaultTimeout, "longest a request to the server may take")
+
+	err := fs.Parse(os.Args[1:])
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+
+	opts := tictactoe.GameOptions{
+		Shape: tictactoe.Shape{Width: *width, Height: *height, WinLength: *win},
+	}
+
+	var arena tictactoe.Arena
+	if *server == "" {
+		arena = tictactoe.NewLocalArena(opts)
+	} else {
+		arena = &remoteArena{
+			host:    *server,
+			opts:    opts,
+			timeout: *timeout,
+		}
+	}
+
+	// stop after the game being played on ^C
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	start := time.Now()
+
+	result, err := tictactoe.RunTournament(ctx, arena, strings.Split(*names, ","), *rounds)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	standings(result)
+	fmt.Println()
+	headToHead(result)
+	fmt.Println()
+	fmt.Printf("Played in %s\n", time.Since(start).Round(time.Millisecond))
+}
+
+// remoteArena plays every game over the API of a server, with a
+// client for each seat.
+type remoteArena struct {
+	host    string
+	opts    tictactoe.GameOptions
+	timeout time.Duration
+}
+
+func (a *remoteArena) Play(ctx context.Context, x, o tictactoe.Strategy) (tictactoe.GameOutcome, error) {
+
+	seats := map[tictactoe.Symbol]client.Client{}
+	for _, symbol := range []tictactoe.Symbol{tictactoe.X, tictactoe.O} {
+		seats[symbol] = client.New(client.WithHost(a.host), client.WithTimeout(a.timeout))
+	}
+
+	id := tictactoe.GameID("tournament-" + uuid.NewV4().String()[:8])
+
+	if _, err := seats[tictactoe.X].CreateGame(ctx, id, tictactoe.X, a.opts); err != nil {
+		return tictactoe.GameOutcome{}, err
+	}
+	defer seats[tictactoe.X].EndGame(context.Background(), id)
+
+	joined, err := seats[tictactoe.O].JoinGame(ctx, id)
+	if err != nil {
+		return tictactoe.GameOutcome{}, err
+	}
+
+	return tictactoe.PlayOut(ctx, &joined.State, x, o, func(symbol tictactoe.Symbol, index int) (*tictactoe.GameState, error) {
+		return seats[symbol].Move(ctx, id, symbol, index)
+	})
+}
+
+// standings prints the strategies from the strongest down.
+func standings(result *tictactoe.TournamentResult) {
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tSTRATEGY\tRATING\tWON\tDRAWN\tLOST\tFORFEITED")
+	for i, s := range result.Standings {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%d\t%d\t%d\n", i+1, s.Name, s.Rating, s.Wins, s.Draws, s.Losses, s.Forfeits)
+	}
+	w.Flush()
+}
+
+// headToHead prints the won, drawn and lost games of each strategy
+// in a row against each one in a column.
+func headToHead(result *tictactoe.TournamentResult) {
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprint(w, "W-D-L")
+	for _, s := range result.Standings {
+		fmt.Fprintf(w, "\t%s", s.Name)
+	}
+	fmt.Fprintln(w)
+
+	for _, row := range result.Standings {
+		fmt.Fprint(w, row.Name)
+		for _, col := range result.Standings {
+			if row.Name == col.Name {
+				fmt.Fprint(w, "\t-")
+				continue
+			}
+			r := result.HeadToHead[row.Name][col.Name]
+			fmt.Fprintf(w, "\t%d-%d-%d", r.Wins, r.Draws, r.Losses)
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+}